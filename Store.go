@@ -1,14 +1,20 @@
 package blindindexstore
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"log"
+	"maps"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
 
 	"github.com/dromara/carbon/v2"
+	"github.com/gouniverse/base/database"
 	"github.com/gouniverse/sb"
 	"github.com/samber/lo"
 )
@@ -17,23 +23,28 @@ var _ StoreInterface = (*storeImplementation)(nil) // verify it extends the inte
 
 // storeImplementation implements StoreInterface
 type storeImplementation struct {
-	tableName          string
-	db                 *sql.DB
-	dbDriverName       string
-	automigrateEnabled bool
-	debugEnabled       bool
-	transformer        TransformerInterface
+	tableName            string
+	db                   *sql.DB
+	tx                   *sql.Tx
+	dbDriverName         string
+	automigrateEnabled   bool
+	debugEnabled         bool
+	batchSize            int
+	uniqueSearchValues   bool
+	ngramSize            int
+	normalizers          []NormalizerInterface
+	transformer          TransformerInterface
+	transformerWithError TransformerWithErrorInterface
 }
 
 // AutoMigrate auto migrate
 func (st *storeImplementation) AutoMigrate() error {
-	sqlStr := st.sqlTableCreate()
-
-	if st.debugEnabled {
-		log.Println(sqlStr)
-	}
+	return st.AutoMigrateCtx(context.Background())
+}
 
-	_, err := st.db.Exec(sqlStr)
+// AutoMigrateCtx auto migrate, applies the pending migrations
+func (st *storeImplementation) AutoMigrateCtx(ctx context.Context) error {
+	err := st.MigrateCtx(ctx)
 
 	if err != nil {
 		log.Println(err)
@@ -48,24 +59,143 @@ func (st *storeImplementation) EnableDebug(debug bool) {
 	st.debugEnabled = debug
 }
 
+// Rekey re-transforms the rows created by older versions of the versioned
+// transformer with its current version, and returns the number of re-keyed
+// rows. The plaintext of each row is requested from the source callback.
+func (store *storeImplementation) Rekey(source RekeySourceFunc) (int64, error) {
+	return store.RekeyCtx(context.Background(), source)
+}
+
+// RekeyCtx re-transforms the rows created by older versions of the versioned
+// transformer with its current version, and returns the number of re-keyed
+// rows. The plaintext of each row is requested from the source callback.
+func (store *storeImplementation) RekeyCtx(ctx context.Context, source RekeySourceFunc) (int64, error) {
+	versioned, isVersioned := store.transformer.(VersionedTransformerInterface)
+
+	if !isVersioned {
+		return 0, errors.New("blind index store: rekey requires a versioned transformer")
+	}
+
+	if source == nil {
+		return 0, errors.New("blind index store: rekey source is required")
+	}
+
+	currentVersion := versioned.CurrentVersion()
+	rekeyed := int64(0)
+
+	for {
+		sqlStr, _, errSql := store.queryBuilder().
+			From(store.tableName).
+			Where(goqu.C(COLUMN_TRANSFORMER_VERSION).Neq(currentVersion)).
+			Order(goqu.C(COLUMN_ID).Asc()).
+			Limit(uint(store.batchSize)).
+			ToSQL()
+
+		if errSql != nil {
+			return rekeyed, queryBuildError(errSql)
+		}
+
+		if store.debugEnabled {
+			log.Println(sqlStr)
+		}
+
+		modelMaps, err := database.SelectToMapString(store.toQueryableContext(ctx), sqlStr)
+
+		if err != nil {
+			return rekeyed, err
+		}
+
+		if len(modelMaps) == 0 {
+			return rekeyed, nil
+		}
+
+		for _, modelMap := range modelMaps {
+			searchValue := NewSearchValueFromExistingData(modelMap)
+
+			plaintext, err := source(*searchValue)
+
+			if err != nil {
+				return rekeyed, err
+			}
+
+			transformed, err := transformVersion(versioned, currentVersion, normalize(store.normalizers, plaintext))
+
+			if err != nil {
+				return rekeyed, err
+			}
+
+			hashes, err := store.valueNgramHashes(ctx, plaintext)
+
+			if err != nil {
+				return rekeyed, err
+			}
+
+			sqlStr, params, errSql := store.queryBuilder().
+				Update(store.tableName).
+				Prepared(true).
+				Set(goqu.Record{
+					COLUMN_SEARCH_VALUE:        transformed,
+					COLUMN_SEARCH_VALUE_HASH:   searchValueHash(transformed),
+					COLUMN_TRANSFORMER_VERSION: currentVersion,
+					COLUMN_UPDATED_AT:          carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC),
+				}).
+				Where(goqu.C(COLUMN_ID).Eq(searchValue.ID())).
+				ToSQL()
+
+			if errSql != nil {
+				return rekeyed, queryBuildError(errSql)
+			}
+
+			if store.debugEnabled {
+				log.Println(sqlStr)
+			}
+
+			err = store.inNgramsTransaction(ctx, func(txStore *storeImplementation) error {
+				if _, err := database.Execute(txStore.toQueryableContext(ctx), sqlStr, params...); err != nil {
+					return err
+				}
+
+				return txStore.ngramsReplace(ctx, searchValue.ID(), hashes)
+			})
+
+			if err != nil {
+				return rekeyed, err
+			}
+
+			rekeyed++
+		}
+	}
+}
+
 func (store *storeImplementation) Search(needle, searchType string) (refIDs []string, err error) {
-	q := store.searchValueQuery(SearchValueQueryOptions{
+	return store.SearchCtx(context.Background(), needle, searchType)
+}
+
+func (store *storeImplementation) SearchCtx(ctx context.Context, needle, searchType string) (refIDs []string, err error) {
+	if err := store.validateSearchType(searchType); err != nil {
+		return []string{}, err
+	}
+
+	q, err := store.searchValueQuery(ctx, SearchValueQueryOptions{
 		SearchValue: needle,
 		SearchType:  searchType,
 	})
 
+	if err != nil {
+		return []string{}, err
+	}
+
 	sqlStr, _, errSql := q.Select().ToSQL()
 
 	if errSql != nil {
-		return refIDs, nil
+		return []string{}, queryBuildError(errSql)
 	}
 
 	if store.debugEnabled {
 		log.Println(sqlStr)
 	}
 
-	db := sb.NewDatabase(store.db, store.dbDriverName)
-	modelMaps, err := db.SelectToMapString(sqlStr)
+	modelMaps, err := database.SelectToMapString(store.toQueryableContext(ctx), sqlStr)
 	if err != nil {
 		return refIDs, err
 	}
@@ -80,30 +210,270 @@ func (store *storeImplementation) Search(needle, searchType string) (refIDs []st
 	return list, nil
 }
 
+// SearchAny searches for any of the needles in a single query,
+// and returns the de-duplicated source reference IDs
+func (store *storeImplementation) SearchAny(needles []string, searchType string) (refIDs []string, err error) {
+	return store.SearchAnyCtx(context.Background(), needles, searchType)
+}
+
+// SearchAnyCtx searches for any of the needles in a single query,
+// and returns the de-duplicated source reference IDs
+func (store *storeImplementation) SearchAnyCtx(ctx context.Context, needles []string, searchType string) (refIDs []string, err error) {
+	if err := store.validateSearchType(searchType); err != nil {
+		return []string{}, err
+	}
+
+	needles = lo.Uniq(lo.Compact(needles))
+
+	if len(needles) == 0 {
+		return []string{}, nil
+	}
+
+	expressions := []exp.Expression{}
+
+	for _, needle := range needles {
+		expression, err := store.searchValueExpression(ctx, needle, searchType)
+
+		if err != nil {
+			return []string{}, err
+		}
+
+		expressions = append(expressions, expression)
+	}
+
+	q, err := store.searchValueQuery(ctx, SearchValueQueryOptions{})
+
+	if err != nil {
+		return []string{}, err
+	}
+
+	q = q.Where(goqu.Or(expressions...))
+
+	sqlStr, _, errSql := q.Select(goqu.C(COLUMN_SOURCE_REFERENCE_ID)).Distinct().ToSQL()
+
+	if errSql != nil {
+		return []string{}, queryBuildError(errSql)
+	}
+
+	if store.debugEnabled {
+		log.Println(sqlStr)
+	}
+
+	modelMaps, err := database.SelectToMapString(store.toQueryableContext(ctx), sqlStr)
+
+	if err != nil {
+		return []string{}, err
+	}
+
+	list := lo.Map(modelMaps, func(modelMap map[string]string, index int) string {
+		return modelMap[COLUMN_SOURCE_REFERENCE_ID]
+	})
+
+	return list, nil
+}
+
+// SearchCount returns the number of entries matching the needle
+func (store *storeImplementation) SearchCount(needle, searchType string) (int64, error) {
+	return store.SearchCountCtx(context.Background(), needle, searchType)
+}
+
+// SearchCountCtx returns the number of entries matching the needle
+func (store *storeImplementation) SearchCountCtx(ctx context.Context, needle, searchType string) (int64, error) {
+	return store.SearchValueCountCtx(ctx, SearchValueQueryOptions{
+		SearchValue: needle,
+		SearchType:  searchType,
+	})
+}
+
+// PurgeSoftDeleted hard deletes the entries soft deleted more than
+// olderThan ago, and returns the number of purged rows. Rows are deleted
+// in batches of the configured batch size, so the table is not locked
+// for the whole purge
+func (store *storeImplementation) PurgeSoftDeleted(olderThan time.Duration) (int64, error) {
+	return store.PurgeSoftDeletedCtx(context.Background(), olderThan)
+}
+
+// PurgeSoftDeletedCtx hard deletes the entries soft deleted more than
+// olderThan ago, and returns the number of purged rows. Rows are deleted
+// in batches of the configured batch size, so the table is not locked
+// for the whole purge
+func (store *storeImplementation) PurgeSoftDeletedCtx(ctx context.Context, olderThan time.Duration) (int64, error) {
+	if olderThan < 0 {
+		olderThan = 0
+	}
+
+	cutoff := carbon.CreateFromStdTime(time.Now().Add(-olderThan), carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	purged := int64(0)
+
+	for {
+		sqlStr, _, errSql := store.queryBuilder().
+			From(store.tableName).
+			Select(goqu.C(COLUMN_ID)).
+			Where(goqu.C(COLUMN_DELETED_AT).Lt(cutoff)).
+			Limit(uint(store.batchSize)).
+			ToSQL()
+
+		if errSql != nil {
+			return purged, queryBuildError(errSql)
+		}
+
+		if store.debugEnabled {
+			log.Println(sqlStr)
+		}
+
+		rows, err := database.SelectToMapString(store.toQueryableContext(ctx), sqlStr)
+
+		if err != nil {
+			return purged, err
+		}
+
+		if len(rows) == 0 {
+			return purged, nil
+		}
+
+		ids := lo.Map(rows, func(row map[string]string, index int) string {
+			return row[COLUMN_ID]
+		})
+
+		sqlStr, params, errSql := store.queryBuilder().
+			Delete(store.tableName).
+			Prepared(true).
+			Where(goqu.C(COLUMN_ID).In(ids)).
+			ToSQL()
+
+		if errSql != nil {
+			return purged, queryBuildError(errSql)
+		}
+
+		if store.debugEnabled {
+			log.Println(sqlStr)
+		}
+
+		affected := int64(0)
+
+		err = store.inNgramsTransaction(ctx, func(txStore *storeImplementation) error {
+			result, err := database.Execute(txStore.toQueryableContext(ctx), sqlStr, params...)
+
+			if err != nil {
+				return err
+			}
+
+			if affected, err = result.RowsAffected(); err != nil {
+				return err
+			}
+
+			return txStore.ngramsDelete(ctx, goqu.C(COLUMN_SEARCH_VALUE_ID).In(ids))
+		})
+
+		if err != nil {
+			return purged, err
+		}
+
+		purged += affected
+
+		if len(rows) < store.batchSize {
+			return purged, nil
+		}
+	}
+}
+
+// SearchValueCount returns the number of entries matching the options
+func (store *storeImplementation) SearchValueCount(options SearchValueQueryOptions) (int64, error) {
+	return store.SearchValueCountCtx(context.Background(), options)
+}
+
+// SearchValueCountCtx returns the number of entries matching the options
+func (store *storeImplementation) SearchValueCountCtx(ctx context.Context, options SearchValueQueryOptions) (int64, error) {
+	if err := store.validateQuerySearchType(options); err != nil {
+		return 0, err
+	}
+
+	options.CountOnly = true
+
+	q, err := store.searchValueQuery(ctx, options)
+
+	if err != nil {
+		return 0, err
+	}
+
+	sqlStr, _, errSql := q.Select(goqu.COUNT(goqu.Star()).As("count")).ToSQL()
+
+	if errSql != nil {
+		return 0, queryBuildError(errSql)
+	}
+
+	if store.debugEnabled {
+		log.Println(sqlStr)
+	}
+
+	mapped, err := database.SelectToMapString(store.toQueryableContext(ctx), sqlStr)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if len(mapped) < 1 {
+		return 0, nil
+	}
+
+	return strconv.ParseInt(mapped[0]["count"], 10, 64)
+}
+
 // SearchValueCreate creates the record
 // Side effect! Transforms the value
 func (store *storeImplementation) SearchValueCreate(searchValue *SearchValue) error {
+	return store.SearchValueCreateCtx(context.Background(), searchValue)
+}
+
+// SearchValueCreateCtx creates the record
+// Side effect! Transforms the value
+func (store *storeImplementation) SearchValueCreateCtx(ctx context.Context, searchValue *SearchValue) error {
+	if searchValue == nil {
+		return ErrNilSearchValue
+	}
+
+	transformed, err := store.transform(ctx, searchValue.SearchValue())
+
+	if err != nil {
+		return err
+	}
+
+	hashes, err := store.valueNgramHashes(ctx, searchValue.SearchValue())
+
+	if err != nil {
+		return err
+	}
+
 	searchValue.SetCreatedAt(carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC))
 	searchValue.SetUpdatedAt(carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC))
-	searchValue.SetSearchValue(store.transformer.Transform(searchValue.SearchValue()))
+	searchValue.SetSearchValue(transformed)
+	searchValue.SetSearchValueHash(searchValueHash(searchValue.SearchValue()))
+	searchValue.SetTransformerVersion(store.transformerVersion())
 
 	data := searchValue.Data()
 
-	sqlStr, params, errSql := goqu.Dialect(store.dbDriverName).
+	sqlStr, params, errSql := store.queryBuilder().
 		Insert(store.tableName).
 		Prepared(true).
 		Rows(data).
 		ToSQL()
 
 	if errSql != nil {
-		return errSql
+		return queryBuildError(errSql)
 	}
 
 	if store.debugEnabled {
 		log.Println(sqlStr)
 	}
 
-	_, err := store.db.Exec(sqlStr, params...)
+	err = store.inNgramsTransaction(ctx, func(txStore *storeImplementation) error {
+		if _, err := database.Execute(txStore.toQueryableContext(ctx), sqlStr, params...); err != nil {
+			return err
+		}
+
+		return txStore.ngramsInsert(ctx, map[string][]string{searchValue.ID(): hashes})
+	})
 
 	if err != nil {
 		return err
@@ -114,44 +484,201 @@ func (store *storeImplementation) SearchValueCreate(searchValue *SearchValue) er
 	return nil
 }
 
+// SearchValueCreateMany creates the records in batches of multi-row inserts,
+// all inside a single transaction. On failure the transaction is rolled
+// back, unless the store is bound to the caller's transaction with WithTx,
+// in which case the batches inserted so far stay in it. A *CreateManyError
+// reports the invalid rows, or all the rows of the failed batch.
+// Side effect! Transforms the values, once the transaction is committed
+func (store *storeImplementation) SearchValueCreateMany(searchValues []*SearchValue) error {
+	return store.SearchValueCreateManyCtx(context.Background(), searchValues)
+}
+
+// SearchValueCreateManyCtx creates the records in batches of multi-row inserts,
+// all inside a single transaction. On failure the transaction is rolled
+// back, unless the store is bound to the caller's transaction with WithTx,
+// in which case the batches inserted so far stay in it. A *CreateManyError
+// reports the invalid rows, or all the rows of the failed batch.
+// Side effect! Transforms the values, once the transaction is committed
+func (store *storeImplementation) SearchValueCreateManyCtx(ctx context.Context, searchValues []*SearchValue) error {
+	if len(searchValues) == 0 {
+		return nil
+	}
+
+	failures := createManyValidate(searchValues)
+
+	if len(failures) > 0 {
+		return &CreateManyError{Failures: failures}
+	}
+
+	now := carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	rows, err := createManyRows(ctx, searchValues, now, store.transform, store.transformerVersion())
+
+	if err != nil {
+		return err
+	}
+
+	hashesByID, err := createManyNgramHashes(ctx, searchValues, store.valueNgramHashes)
+
+	if err != nil {
+		return err
+	}
+
+	err = store.inTransaction(ctx, func(txStore *storeImplementation) error {
+		for start := 0; start < len(rows); start += store.batchSize {
+			end := min(start+store.batchSize, len(rows))
+
+			if err := txStore.insertRows(ctx, rows[start:end]); err != nil {
+				for index := start; index < end; index++ {
+					failures[index] = err
+				}
+
+				return &CreateManyError{Failures: failures}
+			}
+		}
+
+		return txStore.ngramsInsert(ctx, hashesByID)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for index, searchValue := range searchValues {
+		searchValue.SetCreatedAt(rows[index][COLUMN_CREATED_AT])
+		searchValue.SetUpdatedAt(rows[index][COLUMN_UPDATED_AT])
+		searchValue.SetSearchValue(rows[index][COLUMN_SEARCH_VALUE])
+		searchValue.SetSearchValueHash(rows[index][COLUMN_SEARCH_VALUE_HASH])
+		searchValue.SetTransformerVersion(rows[index][COLUMN_TRANSFORMER_VERSION])
+		searchValue.MarkAsNotDirty()
+	}
+
+	return nil
+}
+
 func (store *storeImplementation) SearchValueDelete(searchValue *SearchValue) error {
+	return store.SearchValueDeleteCtx(context.Background(), searchValue)
+}
+
+func (store *storeImplementation) SearchValueDeleteCtx(ctx context.Context, searchValue *SearchValue) error {
 	if searchValue == nil {
-		return errors.New("searchValue is nil")
+		return ErrNilSearchValue
 	}
 
-	return store.SearchValueDeleteByID(searchValue.ID())
+	return store.SearchValueDeleteByIDCtx(ctx, searchValue.ID())
 }
 
 func (store *storeImplementation) SearchValueDeleteByID(id string) error {
+	return store.SearchValueDeleteByIDCtx(context.Background(), id)
+}
+
+func (store *storeImplementation) SearchValueDeleteByIDCtx(ctx context.Context, id string) error {
 	if id == "" {
-		return errors.New("searchValue id is empty")
+		return ErrEmptyID
 	}
 
-	sqlStr, params, errSql := goqu.Dialect(store.dbDriverName).
+	sqlStr, params, errSql := store.queryBuilder().
 		Delete(store.tableName).
 		Prepared(true).
 		Where(goqu.C(COLUMN_ID).Eq(id)).
 		ToSQL()
 
 	if errSql != nil {
-		return errSql
+		return queryBuildError(errSql)
 	}
 
 	if store.debugEnabled {
 		log.Println(sqlStr)
 	}
 
-	_, err := store.db.Exec(sqlStr, params...)
+	return store.inNgramsTransaction(ctx, func(txStore *storeImplementation) error {
+		result, err := database.Execute(txStore.toQueryableContext(ctx), sqlStr, params...)
 
-	return err
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+
+		if err != nil {
+			return err
+		}
+
+		if affected < 1 {
+			return ErrNotFound
+		}
+
+		return txStore.ngramsDelete(ctx, goqu.C(COLUMN_SEARCH_VALUE_ID).Eq(id))
+	})
+}
+
+// SearchValueDeleteBySourceReferenceID hard deletes all the entries
+// (including soft deleted ones) for the source reference, and returns
+// the number of deleted rows
+func (store *storeImplementation) SearchValueDeleteBySourceReferenceID(sourceReferenceID string) (int64, error) {
+	return store.SearchValueDeleteBySourceReferenceIDCtx(context.Background(), sourceReferenceID)
+}
+
+// SearchValueDeleteBySourceReferenceIDCtx hard deletes all the entries
+// (including soft deleted ones) for the source reference, and returns
+// the number of deleted rows
+func (store *storeImplementation) SearchValueDeleteBySourceReferenceIDCtx(ctx context.Context, sourceReferenceID string) (int64, error) {
+	if sourceReferenceID == "" {
+		return 0, emptySourceReferenceIDError()
+	}
+
+	sqlStr, params, errSql := store.queryBuilder().
+		Delete(store.tableName).
+		Prepared(true).
+		Where(goqu.C(COLUMN_SOURCE_REFERENCE_ID).Eq(sourceReferenceID)).
+		ToSQL()
+
+	if errSql != nil {
+		return 0, queryBuildError(errSql)
+	}
+
+	deleted := int64(0)
+
+	err := store.inNgramsTransaction(ctx, func(txStore *storeImplementation) error {
+		// the n-grams are deleted first, while their search values exist
+		err := txStore.ngramsDelete(ctx, inSubquery(COLUMN_SEARCH_VALUE_ID, txStore.queryBuilder().
+			From(txStore.tableName).
+			Select(goqu.C(COLUMN_ID)).
+			Where(goqu.C(COLUMN_SOURCE_REFERENCE_ID).Eq(sourceReferenceID))))
+
+		if err != nil {
+			return err
+		}
+
+		if txStore.debugEnabled {
+			log.Println(sqlStr)
+		}
+
+		result, err := database.Execute(txStore.toQueryableContext(ctx), sqlStr, params...)
+
+		if err != nil {
+			return err
+		}
+
+		deleted, err = result.RowsAffected()
+
+		return err
+	})
+
+	return deleted, err
 }
 
 func (store *storeImplementation) SearchValueFindByID(id string) (*SearchValue, error) {
+	return store.SearchValueFindByIDCtx(context.Background(), id)
+}
+
+func (store *storeImplementation) SearchValueFindByIDCtx(ctx context.Context, id string) (*SearchValue, error) {
 	if id == "" {
-		return nil, errors.New("searchValue id is empty")
+		return nil, ErrEmptyID
 	}
 
-	list, err := store.SearchValueList(SearchValueQueryOptions{
+	list, err := store.SearchValueListCtx(ctx, SearchValueQueryOptions{
 		ID:    id,
 		Limit: 1,
 	})
@@ -160,19 +687,23 @@ func (store *storeImplementation) SearchValueFindByID(id string) (*SearchValue,
 		return nil, err
 	}
 
-	if len(list) > 0 {
-		return &list[0], nil
+	if len(list) < 1 {
+		return nil, ErrNotFound
 	}
 
-	return nil, nil
+	return &list[0], nil
 }
 
 func (store *storeImplementation) SearchValueFindBySourceReferenceID(sourceReferenceID string) (*SearchValue, error) {
+	return store.SearchValueFindBySourceReferenceIDCtx(context.Background(), sourceReferenceID)
+}
+
+func (store *storeImplementation) SearchValueFindBySourceReferenceIDCtx(ctx context.Context, sourceReferenceID string) (*SearchValue, error) {
 	if sourceReferenceID == "" {
-		return nil, errors.New("searchValue objectID is empty")
+		return nil, emptySourceReferenceIDError()
 	}
 
-	list, err := store.SearchValueList(SearchValueQueryOptions{
+	list, err := store.SearchValueListCtx(ctx, SearchValueQueryOptions{
 		SourceReferenceID: sourceReferenceID,
 		Limit:             1,
 	})
@@ -181,28 +712,39 @@ func (store *storeImplementation) SearchValueFindBySourceReferenceID(sourceRefer
 		return nil, err
 	}
 
-	if len(list) > 0 {
-		return &list[0], nil
+	if len(list) < 1 {
+		return nil, ErrNotFound
 	}
 
-	return nil, nil
+	return &list[0], nil
 }
 
 func (store *storeImplementation) SearchValueList(options SearchValueQueryOptions) ([]SearchValue, error) {
-	q := store.searchValueQuery(options)
+	return store.SearchValueListCtx(context.Background(), options)
+}
+
+func (store *storeImplementation) SearchValueListCtx(ctx context.Context, options SearchValueQueryOptions) ([]SearchValue, error) {
+	if err := store.validateQuerySearchType(options); err != nil {
+		return []SearchValue{}, err
+	}
+
+	q, err := store.searchValueQuery(ctx, options)
+
+	if err != nil {
+		return []SearchValue{}, err
+	}
 
 	sqlStr, _, errSql := q.Select().ToSQL()
 
 	if errSql != nil {
-		return []SearchValue{}, nil
+		return []SearchValue{}, queryBuildError(errSql)
 	}
 
 	if store.debugEnabled {
 		log.Println(sqlStr)
 	}
 
-	db := sb.NewDatabase(store.db, store.dbDriverName)
-	modelMaps, err := db.SelectToMapString(sqlStr)
+	modelMaps, err := database.SelectToMapString(store.toQueryableContext(ctx), sqlStr)
 	if err != nil {
 		return []SearchValue{}, err
 	}
@@ -217,31 +759,207 @@ func (store *storeImplementation) SearchValueList(options SearchValueQueryOption
 	return list, nil
 }
 
+// SearchValueReplaceForSourceReference replaces all the index entries for
+// the source reference with the given values, in a single transaction.
+// The existing entries (including soft deleted ones) are hard deleted.
+func (store *storeImplementation) SearchValueReplaceForSourceReference(sourceReferenceID string, values []string) error {
+	return store.SearchValueReplaceForSourceReferenceCtx(context.Background(), sourceReferenceID, values)
+}
+
+// SearchValueReplaceForSourceReferenceCtx replaces all the index entries for
+// the source reference with the given values, in a single transaction.
+// The existing entries (including soft deleted ones) are hard deleted.
+func (store *storeImplementation) SearchValueReplaceForSourceReferenceCtx(ctx context.Context, sourceReferenceID string, values []string) error {
+	if sourceReferenceID == "" {
+		return emptySourceReferenceIDError()
+	}
+
+	searchValues := lo.Map(values, func(value string, index int) *SearchValue {
+		return NewSearchValue().
+			SetSourceReferenceID(sourceReferenceID).
+			SetSearchValue(value)
+	})
+
+	return store.inTransaction(ctx, func(txStore *storeImplementation) error {
+		_, err := txStore.SearchValueDeleteBySourceReferenceIDCtx(ctx, sourceReferenceID)
+
+		if err != nil {
+			return err
+		}
+
+		return txStore.SearchValueCreateManyCtx(ctx, searchValues)
+	})
+}
+
+// SearchValueRestore restores a soft deleted record
+func (store *storeImplementation) SearchValueRestore(searchValue *SearchValue) error {
+	return store.SearchValueRestoreCtx(context.Background(), searchValue)
+}
+
+// SearchValueRestoreCtx restores a soft deleted record
+func (store *storeImplementation) SearchValueRestoreCtx(ctx context.Context, searchValue *SearchValue) error {
+	if searchValue == nil {
+		return ErrNilSearchValue
+	}
+
+	searchValue.SetDeletedAt(sb.MAX_DATETIME)
+
+	return store.SearchValueUpdateCtx(ctx, searchValue)
+}
+
+// SearchValueRestoreByID restores a soft deleted record by ID
+func (store *storeImplementation) SearchValueRestoreByID(id string) error {
+	return store.SearchValueRestoreByIDCtx(context.Background(), id)
+}
+
+// SearchValueRestoreByIDCtx restores a soft deleted record by ID
+func (store *storeImplementation) SearchValueRestoreByIDCtx(ctx context.Context, id string) error {
+	if id == "" {
+		return ErrEmptyID
+	}
+
+	list, err := store.SearchValueListCtx(ctx, SearchValueQueryOptions{
+		ID:          id,
+		OnlyDeleted: true,
+		Limit:       1,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if len(list) < 1 {
+		return ErrNotFound
+	}
+
+	return store.SearchValueRestoreCtx(ctx, &list[0])
+}
+
+// SearchValueRestoreBySourceReferenceID restores all the soft deleted entries
+// for the source reference, and returns the number of restored rows
+func (store *storeImplementation) SearchValueRestoreBySourceReferenceID(sourceReferenceID string) (int64, error) {
+	return store.SearchValueRestoreBySourceReferenceIDCtx(context.Background(), sourceReferenceID)
+}
+
+// SearchValueRestoreBySourceReferenceIDCtx restores all the soft deleted entries
+// for the source reference, and returns the number of restored rows
+func (store *storeImplementation) SearchValueRestoreBySourceReferenceIDCtx(ctx context.Context, sourceReferenceID string) (int64, error) {
+	if sourceReferenceID == "" {
+		return 0, emptySourceReferenceIDError()
+	}
+
+	now := carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	sqlStr, params, errSql := store.queryBuilder().
+		Update(store.tableName).
+		Prepared(true).
+		Set(goqu.Record{
+			COLUMN_DELETED_AT: sb.MAX_DATETIME,
+			COLUMN_UPDATED_AT: now,
+		}).
+		Where(goqu.C(COLUMN_SOURCE_REFERENCE_ID).Eq(sourceReferenceID)).
+		Where(goqu.C(COLUMN_DELETED_AT).Lte(now)).
+		ToSQL()
+
+	if errSql != nil {
+		return 0, queryBuildError(errSql)
+	}
+
+	if store.debugEnabled {
+		log.Println(sqlStr)
+	}
+
+	result, err := database.Execute(store.toQueryableContext(ctx), sqlStr, params...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
 func (store *storeImplementation) SearchValueSoftDelete(searchValue *SearchValue) error {
+	return store.SearchValueSoftDeleteCtx(context.Background(), searchValue)
+}
+
+func (store *storeImplementation) SearchValueSoftDeleteCtx(ctx context.Context, searchValue *SearchValue) error {
 	if searchValue == nil {
-		return errors.New("searchValue is nil")
+		return ErrNilSearchValue
 	}
 
 	searchValue.SetDeletedAt(carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC))
 
-	return store.SearchValueUpdate(searchValue)
+	return store.SearchValueUpdateCtx(ctx, searchValue)
 }
 
 func (store *storeImplementation) SearchValueSoftDeleteByID(id string) error {
-	searchValue, err := store.SearchValueFindByID(id)
+	return store.SearchValueSoftDeleteByIDCtx(context.Background(), id)
+}
+
+func (store *storeImplementation) SearchValueSoftDeleteByIDCtx(ctx context.Context, id string) error {
+	searchValue, err := store.SearchValueFindByIDCtx(ctx, id)
 
 	if err != nil {
 		return err
 	}
 
-	return store.SearchValueSoftDelete(searchValue)
+	return store.SearchValueSoftDeleteCtx(ctx, searchValue)
+}
+
+// SearchValueSoftDeleteBySourceReferenceID soft deletes all the entries
+// for the source reference, and returns the number of soft deleted rows
+func (store *storeImplementation) SearchValueSoftDeleteBySourceReferenceID(sourceReferenceID string) (int64, error) {
+	return store.SearchValueSoftDeleteBySourceReferenceIDCtx(context.Background(), sourceReferenceID)
 }
 
-// SearchValueUpdate updates the record
+// SearchValueSoftDeleteBySourceReferenceIDCtx soft deletes all the entries
+// for the source reference, and returns the number of soft deleted rows
+func (store *storeImplementation) SearchValueSoftDeleteBySourceReferenceIDCtx(ctx context.Context, sourceReferenceID string) (int64, error) {
+	if sourceReferenceID == "" {
+		return 0, emptySourceReferenceIDError()
+	}
+
+	now := carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	sqlStr, params, errSql := store.queryBuilder().
+		Update(store.tableName).
+		Prepared(true).
+		Set(goqu.Record{
+			COLUMN_DELETED_AT: now,
+			COLUMN_UPDATED_AT: now,
+		}).
+		Where(goqu.C(COLUMN_SOURCE_REFERENCE_ID).Eq(sourceReferenceID)).
+		Where(goqu.C(COLUMN_DELETED_AT).Gt(now)).
+		ToSQL()
+
+	if errSql != nil {
+		return 0, queryBuildError(errSql)
+	}
+
+	if store.debugEnabled {
+		log.Println(sqlStr)
+	}
+
+	result, err := database.Execute(store.toQueryableContext(ctx), sqlStr, params...)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// SearchValueUpdate updates the record, or returns ErrNotFound if it does not exist
 // Side effect! Transforms the value, use with caution
 func (store *storeImplementation) SearchValueUpdate(searchValue *SearchValue) error {
+	return store.SearchValueUpdateCtx(context.Background(), searchValue)
+}
+
+// SearchValueUpdateCtx updates the record, or returns ErrNotFound if it does not exist
+// Side effect! Transforms the value, use with caution
+func (store *storeImplementation) SearchValueUpdateCtx(ctx context.Context, searchValue *SearchValue) error {
 	if searchValue == nil {
-		return errors.New("order is nil")
+		return ErrNilSearchValue
 	}
 
 	searchValue.SetUpdatedAt(carbon.Now(carbon.UTC).ToDateTimeString())
@@ -256,12 +974,27 @@ func (store *storeImplementation) SearchValueUpdate(searchValue *SearchValue) er
 		return nil
 	}
 
+	var hashes []string
+
 	if lo.HasKey(dataChanged, COLUMN_SEARCH_VALUE) {
-		searchValue.SetSearchValue(store.transformer.Transform(searchValue.SearchValue()))
-		dataChanged[COLUMN_SEARCH_VALUE] = searchValue.SearchValue()
+		transformed, err := store.transform(ctx, searchValue.SearchValue())
+
+		if err != nil {
+			return err
+		}
+
+		hashes, err = store.valueNgramHashes(ctx, searchValue.SearchValue())
+
+		if err != nil {
+			return err
+		}
+
+		dataChanged[COLUMN_SEARCH_VALUE] = transformed
+		dataChanged[COLUMN_SEARCH_VALUE_HASH] = searchValueHash(transformed)
+		dataChanged[COLUMN_TRANSFORMER_VERSION] = store.transformerVersion()
 	}
 
-	sqlStr, params, errSql := goqu.Dialect(store.dbDriverName).
+	sqlStr, params, errSql := store.queryBuilder().
 		Update(store.tableName).
 		Prepared(true).
 		Set(dataChanged).
@@ -269,18 +1002,62 @@ func (store *storeImplementation) SearchValueUpdate(searchValue *SearchValue) er
 		ToSQL()
 
 	if errSql != nil {
-		return errSql
+		return queryBuildError(errSql)
 	}
 
 	if store.debugEnabled {
 		log.Println(sqlStr)
 	}
 
-	_, err := store.db.Exec(sqlStr, params...)
+	err := store.inNgramsTransaction(ctx, func(txStore *storeImplementation) error {
+		result, err := database.Execute(txStore.toQueryableContext(ctx), sqlStr, params...)
+
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+
+		if err != nil {
+			return err
+		}
+
+		if affected < 1 {
+			// MySQL reports the changed rows only, check the row exists
+			count, err := txStore.SearchValueCountCtx(ctx, SearchValueQueryOptions{
+				ID:          searchValue.ID(),
+				WithDeleted: true,
+			})
+
+			if err != nil {
+				return err
+			}
+
+			if count < 1 {
+				return ErrNotFound
+			}
+		}
+
+		if hashes == nil {
+			return nil // the search value is unchanged
+		}
+
+		return txStore.ngramsReplace(ctx, searchValue.ID(), hashes)
+	})
+
+	if err != nil {
+		return err // the value stays dirty, so the update can be retried
+	}
+
+	if hashes != nil {
+		searchValue.SetSearchValue(dataChanged[COLUMN_SEARCH_VALUE])
+		searchValue.SetSearchValueHash(dataChanged[COLUMN_SEARCH_VALUE_HASH])
+		searchValue.SetTransformerVersion(dataChanged[COLUMN_TRANSFORMER_VERSION])
+	}
 
 	searchValue.MarkAsNotDirty()
 
-	return err
+	return nil
 }
 
 // IsAutomigrateEnabled returns whether automigrate is enabled
@@ -289,46 +1066,151 @@ func (st *storeImplementation) IsAutomigrateEnabled() bool {
 }
 
 func (store *storeImplementation) Truncate() error {
-	sqlStr, _, errSql := goqu.Dialect(store.dbDriverName).
-		Truncate(store.tableName).
+	return store.TruncateCtx(context.Background())
+}
+
+func (store *storeImplementation) TruncateCtx(ctx context.Context) error {
+	tableNames := []string{store.tableName}
+	if store.ngramSize > 0 {
+		tableNames = append(tableNames, store.ngramsTableName())
+	}
+
+	for _, tableName := range tableNames {
+		sqlStr, _, errSql := store.queryBuilder().
+			Truncate(tableName).
+			ToSQL()
+
+		if errSql != nil {
+			return queryBuildError(errSql)
+		}
+
+		if store.debugEnabled {
+			log.Println(sqlStr)
+		}
+
+		if _, err := database.Execute(store.toQueryableContext(ctx), sqlStr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// inTransaction runs fn inside a transaction. If the store is already
+// bound to a transaction it is reused, otherwise a new one is started,
+// and committed or rolled back depending on the result of fn
+func (store *storeImplementation) inTransaction(ctx context.Context, fn func(txStore *storeImplementation) error) error {
+	if store.tx != nil {
+		return fn(store)
+	}
+
+	tx, err := store.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return err
+	}
+
+	txStore := *store
+	txStore.tx = tx
+
+	if err := fn(&txStore); err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			log.Println(errRollback)
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertRows inserts the rows with a single multi-row insert
+func (store *storeImplementation) insertRows(ctx context.Context, rows []map[string]string) error {
+	records := lo.Map(rows, func(row map[string]string, index int) any {
+		return row
+	})
+
+	sqlStr, params, errSql := store.queryBuilder().
+		Insert(store.tableName).
+		Prepared(true).
+		Rows(records...).
 		ToSQL()
 
 	if errSql != nil {
-		return errSql
+		return queryBuildError(errSql)
 	}
 
 	if store.debugEnabled {
 		log.Println(sqlStr)
 	}
 
-	_, err := store.db.Exec(sqlStr)
+	_, err := database.Execute(store.toQueryableContext(ctx), sqlStr, params...)
 
 	return err
 }
 
-func (store *storeImplementation) searchValueQuery(options SearchValueQueryOptions) *goqu.SelectDataset {
-	q := goqu.Dialect(store.dbDriverName).From(store.tableName)
+// WithTx returns a copy of the store bound to the given transaction.
+// All operations of the returned store run inside the transaction,
+// committing or rolling it back is the responsibility of the caller.
+func (store *storeImplementation) WithTx(tx *sql.Tx) StoreInterface {
+	txStore := *store
+	txStore.tx = tx
+	return &txStore
+}
+
+// toQueryableContext binds the context to the transaction, if the store
+// is bound to one, or to the database connection otherwise
+func (store *storeImplementation) toQueryableContext(ctx context.Context) database.QueryableContext {
+	if store.tx != nil {
+		return database.NewQueryableContext(ctx, store.tx)
+	}
+
+	return database.NewQueryableContext(ctx, store.db)
+}
+
+func (store *storeImplementation) searchValueQuery(ctx context.Context, options SearchValueQueryOptions) (*goqu.SelectDataset, error) {
+	q := store.queryBuilder().From(store.tableName)
 
 	if options.ID != "" {
 		q = q.Where(goqu.C("id").Eq(options.ID))
 	}
 
+	if len(options.IDIn) > 0 {
+		q = q.Where(goqu.C(COLUMN_ID).In(options.IDIn))
+	}
+
 	if options.SourceReferenceID != "" {
 		q = q.Where(goqu.C(COLUMN_SOURCE_REFERENCE_ID).Eq(options.SourceReferenceID))
 	}
 
+	if len(options.SourceReferenceIDIn) > 0 {
+		q = q.Where(goqu.C(COLUMN_SOURCE_REFERENCE_ID).In(options.SourceReferenceIDIn))
+	}
+
+	if len(options.SearchValueIn) > 0 {
+		expressions := []exp.Expression{}
+
+		for _, value := range options.SearchValueIn {
+			expression, err := store.searchValueExpression(ctx, value, SEARCH_TYPE_EQUALS)
+
+			if err != nil {
+				return nil, err
+			}
+
+			expressions = append(expressions, expression)
+		}
+
+		q = q.Where(goqu.Or(expressions...))
+	}
+
 	if options.SearchValue != "" {
-		options.SearchValue = store.transformer.Transform(options.SearchValue)
-		if options.SearchType == SEARCH_TYPE_CONTAINS {
-			q = q.Where(goqu.C(COLUMN_SEARCH_VALUE).Like("%" + options.SearchValue + "%"))
-		} else if options.SearchType == SEARCH_TYPE_STARTS_WITH {
-			q = q.Where(goqu.C(COLUMN_SEARCH_VALUE).Like(options.SearchValue + "%"))
-		} else if options.SearchType == SEARCH_TYPE_ENDS_WITH {
-			q = q.Where(goqu.C(COLUMN_SEARCH_VALUE).Like(options.SearchValue + "%"))
-		} else {
-			// default to strict search
-			q = q.Where(goqu.C(COLUMN_SEARCH_VALUE).Eq(options.SearchValue))
+		expression, err := store.searchValueExpression(ctx, options.SearchValue, options.SearchType)
+
+		if err != nil {
+			return nil, err
 		}
+
+		q = q.Where(expression)
 	}
 
 	if !options.CountOnly {
@@ -346,7 +1228,7 @@ func (store *storeImplementation) searchValueQuery(options SearchValueQueryOptio
 		sortOrder = options.SortOrder
 	}
 
-	if options.OrderBy != "" {
+	if options.OrderBy != "" && !options.CountOnly {
 		if strings.EqualFold(sortOrder, sb.ASC) {
 			q = q.Order(goqu.I(options.OrderBy).Asc())
 		} else {
@@ -354,9 +1236,182 @@ func (store *storeImplementation) searchValueQuery(options SearchValueQueryOptio
 		}
 	}
 
-	if !options.WithDeleted {
+	if options.OnlyDeleted {
+		q = q.Where(goqu.C(COLUMN_DELETED_AT).Lte(carbon.Now(carbon.UTC).ToDateTimeString()))
+	} else if !options.WithDeleted {
 		q = q.Where(goqu.C(COLUMN_DELETED_AT).Gt(carbon.Now(carbon.UTC).ToDateTimeString()))
 	}
 
-	return q
+	return q, nil
+}
+
+// searchValueExpression transforms the needle and returns the condition
+// matching it for the search type. With a versioned transformer the needle
+// is transformed with every active version, and matched against the rows
+// of that version
+func (store *storeImplementation) searchValueExpression(ctx context.Context, needle, searchType string) (exp.Expression, error) {
+	if searchType == SEARCH_TYPE_CONTAINS && store.ngramSize > 0 {
+		return store.ngramsExpression(ctx, needle)
+	}
+
+	versioned, isVersioned := store.transformer.(VersionedTransformerInterface)
+
+	if !isVersioned {
+		transformed, err := store.transform(ctx, needle)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return searchValueCondition(transformed, searchType), nil
+	}
+
+	versions, err := searchVersions(versioned)
+
+	if err != nil {
+		return nil, err
+	}
+
+	expressions := []exp.Expression{}
+
+	for _, version := range versions {
+		transformed, err := transformVersion(versioned, version, normalize(store.normalizers, needle))
+
+		if err != nil {
+			return nil, err
+		}
+
+		expressions = append(expressions, goqu.And(
+			goqu.C(COLUMN_TRANSFORMER_VERSION).Eq(version),
+			searchValueCondition(transformed, searchType),
+		))
+	}
+
+	return goqu.Or(expressions...), nil
+}
+
+// validateSearchType checks the search type is a known one, and is
+// supported by the transformer, or by the n-gram mode for SEARCH_TYPE_CONTAINS
+func (store *storeImplementation) validateSearchType(searchType string) error {
+	if searchType == SEARCH_TYPE_CONTAINS && store.ngramSize > 0 {
+		return nil
+	}
+
+	return validateTransformerSearchType(searchType, store.transformer, store.transformerWithError)
+}
+
+// validateQuerySearchType checks the search type of the query options,
+// which only applies when a search value is given
+func (store *storeImplementation) validateQuerySearchType(options SearchValueQueryOptions) error {
+	if options.SearchValue == "" {
+		return nil
+	}
+
+	return store.validateSearchType(options.SearchType)
+}
+
+// transform normalizes and transforms the plaintext value,
+// with the transformer returning errors if one is set
+func (store *storeImplementation) transform(ctx context.Context, v string) (string, error) {
+	return transformValue(ctx, store.transformer, store.transformerWithError, normalize(store.normalizers, v))
+}
+
+// transformerVersion returns the transformer version used for new rows,
+// empty if the transformer is not versioned
+func (store *storeImplementation) transformerVersion() string {
+	return currentTransformerVersion(store.transformer)
+}
+
+// currentTransformerVersion returns the current version of the transformer,
+// empty if the transformer is not versioned
+func currentTransformerVersion(transformer TransformerInterface) string {
+	if versioned, isVersioned := transformer.(VersionedTransformerInterface); isVersioned {
+		return versioned.CurrentVersion()
+	}
+
+	return ""
+}
+
+// createManyRows returns the rows inserted by SearchValueCreateMany, with
+// the transformed search values. Transformer failures are reported in a
+// *CreateManyError
+func createManyRows(ctx context.Context, searchValues []*SearchValue, now string, transform func(ctx context.Context, v string) (string, error), transformerVersion string) ([]map[string]string, error) {
+	rows := make([]map[string]string, 0, len(searchValues))
+	failures := map[int]error{}
+
+	for index, searchValue := range searchValues {
+		transformed, err := transform(ctx, searchValue.SearchValue())
+
+		if err != nil {
+			failures[index] = err
+			continue
+		}
+
+		data := maps.Clone(searchValue.Data())
+		data[COLUMN_CREATED_AT] = now
+		data[COLUMN_UPDATED_AT] = now
+		data[COLUMN_SEARCH_VALUE] = transformed
+		data[COLUMN_SEARCH_VALUE_HASH] = searchValueHash(transformed)
+		data[COLUMN_TRANSFORMER_VERSION] = transformerVersion
+		rows = append(rows, data)
+	}
+
+	if len(failures) > 0 {
+		return nil, &CreateManyError{Failures: failures}
+	}
+
+	return rows, nil
+}
+
+// createManyValidate validates the search values passed to
+// SearchValueCreateMany, and returns the failures by row index
+func createManyValidate(searchValues []*SearchValue) map[int]error {
+	failures := map[int]error{}
+	seenIDs := map[string]int{}
+
+	for index, searchValue := range searchValues {
+		if searchValue == nil {
+			failures[index] = ErrNilSearchValue
+			continue
+		}
+
+		if searchValue.ID() == "" {
+			failures[index] = ErrEmptyID
+			continue
+		}
+
+		if firstIndex, exists := seenIDs[searchValue.ID()]; exists {
+			failures[index] = duplicateIDError(firstIndex)
+			continue
+		}
+
+		seenIDs[searchValue.ID()] = index
+	}
+
+	return failures
+}
+
+// searchValueCondition returns the condition matching the already
+// transformed needle for the search type
+func searchValueCondition(needle, searchType string) exp.Expression {
+	if searchType == SEARCH_TYPE_CONTAINS {
+		return goqu.C(COLUMN_SEARCH_VALUE).Like("%" + needle + "%")
+	} else if searchType == SEARCH_TYPE_STARTS_WITH {
+		return goqu.C(COLUMN_SEARCH_VALUE).Like(needle + "%")
+	} else if searchType == SEARCH_TYPE_ENDS_WITH {
+		return goqu.C(COLUMN_SEARCH_VALUE).Like("%" + needle)
+	}
+
+	// default to strict search, the indexed hash column narrows
+	// down the rows, as the search value column cannot be indexed
+	return goqu.And(
+		goqu.C(COLUMN_SEARCH_VALUE_HASH).Eq(searchValueHash(needle)),
+		goqu.C(COLUMN_SEARCH_VALUE).Eq(needle),
+	)
+}
+
+// searchValueHash returns the fixed-length hash of the transformed
+// search value, stored in the indexed search_value_hash column
+func searchValueHash(transformedValue string) string {
+	return sha256Transform(transformedValue)
 }
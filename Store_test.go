@@ -1,12 +1,18 @@
 package blindindexstore
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/dromara/carbon/v2"
 	"github.com/gouniverse/sb"
+	"github.com/samber/lo"
 	_ "modernc.org/sqlite"
 )
 
@@ -240,8 +246,8 @@ func Test_Store_SearchValueDelete(t *testing.T) {
 
 	valueFound, errFind := store.SearchValueFindByID(value.ID())
 
-	if errFind != nil {
-		t.Fatal("unexpected error:", errFind)
+	if !errors.Is(errFind, ErrNotFound) {
+		t.Fatal("error MUST be ErrNotFound, found: ", errFind)
 		return
 	}
 
@@ -307,8 +313,8 @@ func Test_Store_SearchValueSoftDelete(t *testing.T) {
 
 	valueFound, errFind := store.SearchValueFindByID(value.ID())
 
-	if errFind != nil {
-		t.Fatal("unexpected error:", errFind)
+	if !errors.Is(errFind, ErrNotFound) {
+		t.Fatal("error MUST be ErrNotFound, found: ", errFind)
 		return
 	}
 
@@ -479,6 +485,60 @@ func Test_Store_SearchContains_NoChangeTransformer(t *testing.T) {
 	}
 }
 
+func Test_Store_SearchEndsWith(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_value_search_ends_with",
+		AutomigrateEnabled: true,
+		Transformer:        &NoChangeTransformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	data := []struct {
+		RefID       string
+		SearchValue string
+	}{
+		{
+			RefID:       "USER01",
+			SearchValue: "john@example.com",
+		},
+		{
+			RefID:       "USER02",
+			SearchValue: "example.com@test.org",
+		},
+	}
+
+	for _, v := range data {
+		value := NewSearchValue().
+			SetSourceReferenceID(v.RefID).
+			SetSearchValue(v.SearchValue)
+
+		if err := store.SearchValueCreate(value); err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+
+	// the needle is matched at the end of the value, not at the start
+	refsFound, errFind := store.Search("example.com", SEARCH_TYPE_ENDS_WITH)
+
+	if errFind != nil {
+		t.Fatal("unexpected error:", errFind)
+	}
+
+	if len(refsFound) != 1 {
+		t.Fatal("Search MUST return exactly 1 reference. Returned: ", len(refsFound))
+	}
+
+	if refsFound[0] != "USER01" {
+		t.Fatal("Reference ID found MUST BE 'USER01', found: ", refsFound[0])
+	}
+}
+
 func Test_Store_SearchContains_Rot13Transformer(t *testing.T) {
 	db := initDB(":memory:")
 
@@ -612,8 +672,14 @@ func Test_Store_SearchContains_Sha256Transformer(t *testing.T) {
 
 	refsFound, errFind := store.Search("st02", SEARCH_TYPE_CONTAINS)
 
-	if errFind != nil {
-		t.Fatal("unexpected error:", errFind)
+	var unsupportedError *UnsupportedSearchTypeError
+	if !errors.As(errFind, &unsupportedError) || !errors.Is(errFind, ErrUnsupportedSearchType) {
+		t.Fatal("error MUST BE an UnsupportedSearchTypeError, found: ", errFind)
+		return
+	}
+
+	if unsupportedError.SearchType != SEARCH_TYPE_CONTAINS {
+		t.Fatal("Search type MUST BE 'contains', found: ", unsupportedError.SearchType)
 		return
 	}
 
@@ -716,3 +782,1007 @@ func Test_Store_SearchContains_UniTransformer(t *testing.T) {
 		return
 	}
 }
+
+func Test_Store_CtxCancellation(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_ctx_cancellation",
+		AutomigrateEnabled: true,
+		Transformer:        &NoChangeTransformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	value := NewSearchValue().
+		SetSourceReferenceID("RefId01").
+		SetSearchValue("SearchValue01")
+
+	err = store.SearchValueCreateCtx(context.Background(), value)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	refIDs, err := store.SearchCtx(context.Background(), "SearchValue01", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 {
+		t.Fatal("Search MUST return 1 reference ID, found: ", len(refIDs))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = store.SearchCtx(ctx, "SearchValue01", SEARCH_TYPE_EQUALS)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("Search MUST fail with context.Canceled, found: ", err)
+	}
+
+	err = store.SearchValueCreateCtx(ctx, NewSearchValue().
+		SetSourceReferenceID("RefId02").
+		SetSearchValue("SearchValue02"))
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatal("SearchValueCreate MUST fail with context.Canceled, found: ", err)
+	}
+
+	list, err := store.SearchValueList(SearchValueQueryOptions{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(list) != 1 {
+		t.Fatal("Search values MUST be 1, found: ", len(list))
+	}
+}
+
+func Test_Store_WithTx(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_with_tx",
+		AutomigrateEnabled: true,
+		Transformer:        &NoChangeTransformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	// Rolled back transaction
+	tx, err := db.Begin()
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.WithTx(tx).SearchValueCreate(NewSearchValue().
+		SetSourceReferenceID("RefId01").
+		SetSearchValue("SearchValue01"))
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	list, err := store.WithTx(tx).SearchValueList(SearchValueQueryOptions{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(list) != 1 {
+		t.Fatal("Search values inside the transaction MUST be 1, found: ", len(list))
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	list, err = store.SearchValueList(SearchValueQueryOptions{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(list) != 0 {
+		t.Fatal("Search values after rollback MUST be 0, found: ", len(list))
+	}
+
+	// Committed transaction
+	tx, err = db.Begin()
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.WithTx(tx).SearchValueCreate(NewSearchValue().
+		SetSourceReferenceID("RefId02").
+		SetSearchValue("SearchValue02"))
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	refIDs, err := store.Search("SearchValue02", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefId02" {
+		t.Fatal("Search MUST return [RefId02], found: ", refIDs)
+	}
+}
+
+func Test_Store_SearchValueCreateMany(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_value_create_many",
+		AutomigrateEnabled: true,
+		Transformer:        &Sha256Transformer{},
+		BatchSize:          2,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	values := []*SearchValue{}
+	for i := 1; i <= 5; i++ {
+		values = append(values, NewSearchValue().
+			SetSourceReferenceID("RefId0"+strconv.Itoa(i)).
+			SetSearchValue("SearchValue0"+strconv.Itoa(i)))
+	}
+
+	err = store.SearchValueCreateMany(values)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if values[0].SearchValue() != "ef46c0effb3e3a6d65fbbd46c039008205e67b8089339db1852ca0992804afb9" {
+		t.Fatal("Search value MUST BE 'ef46c0effb3e3a6d65fbbd46c039008205e67b8089339db1852ca0992804afb9', found: ", values[0].SearchValue())
+	}
+
+	list, err := store.SearchValueList(SearchValueQueryOptions{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(list) != 5 {
+		t.Fatal("Search values MUST be 5, found: ", len(list))
+	}
+
+	refIDs, err := store.Search("SearchValue03", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefId03" {
+		t.Fatal("Search MUST return [RefId03], found: ", refIDs)
+	}
+
+	// Invalid rows are reported, nothing is inserted
+	invalid := []*SearchValue{
+		NewSearchValue().SetSourceReferenceID("RefId06").SetSearchValue("SearchValue06"),
+		nil,
+		NewSearchValue().SetID("").SetSourceReferenceID("RefId07").SetSearchValue("SearchValue07"),
+	}
+
+	err = store.SearchValueCreateMany(invalid)
+
+	var createManyError *CreateManyError
+	if !errors.As(err, &createManyError) {
+		t.Fatal("error MUST be a CreateManyError, found: ", err)
+	}
+
+	if len(createManyError.Failures) != 2 || createManyError.Failures[1] == nil || createManyError.Failures[2] == nil {
+		t.Fatal("Failures MUST be reported for rows 1 and 2, found: ", createManyError.Failures)
+	}
+
+	// A failing batch rolls back the whole transaction
+	duplicate := []*SearchValue{
+		NewSearchValue().SetSourceReferenceID("RefId08").SetSearchValue("SearchValue08"),
+		NewSearchValue().SetSourceReferenceID("RefId09").SetSearchValue("SearchValue09"),
+		NewSearchValue().SetID(values[0].ID()).SetSourceReferenceID("RefId10").SetSearchValue("SearchValue10"),
+	}
+
+	err = store.SearchValueCreateMany(duplicate)
+
+	if !errors.As(err, &createManyError) {
+		t.Fatal("error MUST be a CreateManyError, found: ", err)
+	}
+
+	if len(createManyError.Failures) != 1 || createManyError.Failures[2] == nil {
+		t.Fatal("Failure MUST be reported for row 2, found: ", createManyError.Failures)
+	}
+
+	if duplicate[0].SearchValue() != "SearchValue08" {
+		t.Fatal("Search value MUST NOT be transformed on failure, found: ", duplicate[0].SearchValue())
+	}
+
+	list, err = store.SearchValueList(SearchValueQueryOptions{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(list) != 5 {
+		t.Fatal("Search values MUST still be 5, found: ", len(list))
+	}
+}
+
+func Test_Store_SearchValueReplaceForSourceReference(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_value_replace",
+		AutomigrateEnabled: true,
+		Transformer:        &NoChangeTransformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.SearchValueCreateMany([]*SearchValue{
+		NewSearchValue().SetSourceReferenceID("RefId01").SetSearchValue("old@test.com"),
+		NewSearchValue().SetSourceReferenceID("RefId01").SetSearchValue("old-alias@test.com"),
+		NewSearchValue().SetSourceReferenceID("RefId02").SetSearchValue("other@test.com"),
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.SearchValueReplaceForSourceReference("RefId01", []string{"new@test.com"})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	list, err := store.SearchValueList(SearchValueQueryOptions{
+		SourceReferenceID: "RefId01",
+		WithDeleted:       true,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(list) != 1 || list[0].SearchValue() != "new@test.com" {
+		t.Fatal("Search values MUST be [new@test.com], found: ", list)
+	}
+
+	refIDs, err := store.Search("old@test.com", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 0 {
+		t.Fatal("Search for the old value MUST return no references, found: ", refIDs)
+	}
+
+	refIDs, err = store.Search("other@test.com", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefId02" {
+		t.Fatal("Other references MUST NOT be affected, found: ", refIDs)
+	}
+
+	err = store.SearchValueReplaceForSourceReference("", []string{"new@test.com"})
+
+	if err == nil {
+		t.Fatal("error MUST NOT be nil for empty source reference id")
+	}
+}
+
+func Test_Store_SearchValueDeleteBySourceReferenceID(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_value_delete_by_source_reference_id",
+		AutomigrateEnabled: true,
+		Transformer:        &NoChangeTransformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.SearchValueCreateMany([]*SearchValue{
+		NewSearchValue().SetSourceReferenceID("RefId01").SetSearchValue("SearchValue01"),
+		NewSearchValue().SetSourceReferenceID("RefId01").SetSearchValue("SearchValue02"),
+		NewSearchValue().SetSourceReferenceID("RefId02").SetSearchValue("SearchValue03"),
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	deleted, err := store.SearchValueDeleteBySourceReferenceID("RefId01")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if deleted != 2 {
+		t.Fatal("Deleted rows MUST be 2, found: ", deleted)
+	}
+
+	list, err := store.SearchValueList(SearchValueQueryOptions{WithDeleted: true})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(list) != 1 || list[0].SourceReferenceID() != "RefId02" {
+		t.Fatal("Only RefId02 MUST remain, found: ", list)
+	}
+
+	_, err = store.SearchValueDeleteBySourceReferenceID("")
+
+	if err == nil {
+		t.Fatal("error MUST NOT be nil for empty source reference id")
+	}
+}
+
+func Test_Store_SearchValueSoftDeleteBySourceReferenceID(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_value_soft_delete_by_source_reference_id",
+		AutomigrateEnabled: true,
+		Transformer:        &NoChangeTransformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.SearchValueCreateMany([]*SearchValue{
+		NewSearchValue().SetSourceReferenceID("RefId01").SetSearchValue("SearchValue01"),
+		NewSearchValue().SetSourceReferenceID("RefId01").SetSearchValue("SearchValue02"),
+		NewSearchValue().SetSourceReferenceID("RefId02").SetSearchValue("SearchValue03"),
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	softDeleted, err := store.SearchValueSoftDeleteBySourceReferenceID("RefId01")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if softDeleted != 2 {
+		t.Fatal("Soft deleted rows MUST be 2, found: ", softDeleted)
+	}
+
+	list, err := store.SearchValueList(SearchValueQueryOptions{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(list) != 1 || list[0].SourceReferenceID() != "RefId02" {
+		t.Fatal("Only RefId02 MUST remain, found: ", list)
+	}
+
+	list, err = store.SearchValueList(SearchValueQueryOptions{WithDeleted: true})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(list) != 3 {
+		t.Fatal("Search values with deleted MUST be 3, found: ", len(list))
+	}
+
+	softDeleted, err = store.SearchValueSoftDeleteBySourceReferenceID("RefId01")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if softDeleted != 0 {
+		t.Fatal("Already soft deleted rows MUST NOT be counted again, found: ", softDeleted)
+	}
+}
+
+func Test_Store_SearchValueCount(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_value_count",
+		AutomigrateEnabled: true,
+		Transformer:        &NoChangeTransformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.SearchValueCreateMany([]*SearchValue{
+		NewSearchValue().SetSourceReferenceID("RefId01").SetSearchValue("john@test.com"),
+		NewSearchValue().SetSourceReferenceID("RefId02").SetSearchValue("jane@test.com"),
+		NewSearchValue().SetSourceReferenceID("RefId03").SetSearchValue("john@example.com"),
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	count, err := store.SearchValueCount(SearchValueQueryOptions{
+		Limit:   1,
+		OrderBy: COLUMN_CREATED_AT,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if count != 3 {
+		t.Fatal("Count MUST be 3 (limit is ignored), found: ", count)
+	}
+
+	_, err = store.SearchValueSoftDeleteBySourceReferenceID("RefId03")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	count, err = store.SearchValueCount(SearchValueQueryOptions{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if count != 2 {
+		t.Fatal("Count MUST be 2, found: ", count)
+	}
+
+	count, err = store.SearchValueCount(SearchValueQueryOptions{WithDeleted: true})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if count != 3 {
+		t.Fatal("Count with deleted MUST be 3, found: ", count)
+	}
+
+	count, err = store.SearchCount("john", SEARCH_TYPE_STARTS_WITH)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if count != 1 {
+		t.Fatal("Search count MUST be 1, found: ", count)
+	}
+}
+
+func Test_Store_SearchValueList_InFilters(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_value_list_in_filters",
+		AutomigrateEnabled: true,
+		Transformer:        &Rot13Transformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	values := []*SearchValue{
+		NewSearchValue().SetSourceReferenceID("RefId01").SetSearchValue("john@test.com"),
+		NewSearchValue().SetSourceReferenceID("RefId02").SetSearchValue("jane@test.com"),
+		NewSearchValue().SetSourceReferenceID("RefId03").SetSearchValue("jim@test.com"),
+	}
+
+	err = store.SearchValueCreateMany(values)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	list, err := store.SearchValueList(SearchValueQueryOptions{
+		IDIn: []string{values[0].ID(), values[2].ID()},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(list) != 2 {
+		t.Fatal("IDIn MUST return 2 values, found: ", len(list))
+	}
+
+	list, err = store.SearchValueList(SearchValueQueryOptions{
+		SourceReferenceIDIn: []string{"RefId02", "RefId03", "RefId04"},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(list) != 2 {
+		t.Fatal("SourceReferenceIDIn MUST return 2 values, found: ", len(list))
+	}
+
+	list, err = store.SearchValueList(SearchValueQueryOptions{
+		SearchValueIn: []string{"john@test.com", "jim@test.com", "unknown@test.com"},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(list) != 2 {
+		t.Fatal("SearchValueIn MUST return 2 values, found: ", len(list))
+	}
+
+	list, err = store.SearchValueList(SearchValueQueryOptions{
+		SourceReferenceIDIn: []string{"RefId01", "RefId02"},
+		SearchValueIn:       []string{"jane@test.com"},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(list) != 1 || list[0].SourceReferenceID() != "RefId02" {
+		t.Fatal("Combined filters MUST return RefId02, found: ", list)
+	}
+}
+
+func Test_Store_SearchAny(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_search_any",
+		AutomigrateEnabled: true,
+		Transformer:        &Rot13Transformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.SearchValueCreateMany([]*SearchValue{
+		NewSearchValue().SetSourceReferenceID("RefId01").SetSearchValue("john@test.com"),
+		NewSearchValue().SetSourceReferenceID("RefId01").SetSearchValue("johnny"),
+		NewSearchValue().SetSourceReferenceID("RefId02").SetSearchValue("jane@test.com"),
+		NewSearchValue().SetSourceReferenceID("RefId03").SetSearchValue("jim@example.com"),
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	refIDs, err := store.SearchAny([]string{"johnny", "john@test.com", "unknown"}, SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefId01" {
+		t.Fatal("SearchAny MUST return de-duplicated [RefId01], found: ", refIDs)
+	}
+
+	refIDs, err = store.SearchAny([]string{"johnny", "jane@test.com"}, SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 2 || !lo.Contains(refIDs, "RefId01") || !lo.Contains(refIDs, "RefId02") {
+		t.Fatal("SearchAny MUST return [RefId01 RefId02], found: ", refIDs)
+	}
+
+	refIDs, err = store.SearchAny([]string{"example.com", "nny"}, SEARCH_TYPE_ENDS_WITH)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 2 || !lo.Contains(refIDs, "RefId01") || !lo.Contains(refIDs, "RefId03") {
+		t.Fatal("SearchAny MUST return [RefId01 RefId03], found: ", refIDs)
+	}
+
+	refIDs, err = store.SearchAny([]string{}, SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 0 {
+		t.Fatal("SearchAny with no needles MUST return no references, found: ", refIDs)
+	}
+}
+
+func Test_Store_Errors(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_errors",
+		AutomigrateEnabled: true,
+		Transformer:        &NoChangeTransformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if _, err := store.SearchValueFindByID("not-existing"); !errors.Is(err, ErrNotFound) {
+		t.Fatal("error MUST be ErrNotFound, found: ", err)
+	}
+
+	if _, err := store.SearchValueFindBySourceReferenceID("not-existing"); !errors.Is(err, ErrNotFound) {
+		t.Fatal("error MUST be ErrNotFound, found: ", err)
+	}
+
+	if err := store.SearchValueSoftDeleteByID("not-existing"); !errors.Is(err, ErrNotFound) {
+		t.Fatal("error MUST be ErrNotFound, found: ", err)
+	}
+
+	if _, err := store.SearchValueFindByID(""); !errors.Is(err, ErrEmptyID) {
+		t.Fatal("error MUST be ErrEmptyID, found: ", err)
+	}
+
+	if _, err := store.SearchValueFindBySourceReferenceID(""); !errors.Is(err, ErrEmptyID) {
+		t.Fatal("error MUST be ErrEmptyID, found: ", err)
+	}
+
+	if err := store.SearchValueDeleteByID(""); !errors.Is(err, ErrEmptyID) {
+		t.Fatal("error MUST be ErrEmptyID, found: ", err)
+	}
+
+	if err := store.SearchValueCreate(nil); !errors.Is(err, ErrNilSearchValue) {
+		t.Fatal("error MUST be ErrNilSearchValue, found: ", err)
+	}
+
+	if err := store.SearchValueUpdate(nil); !errors.Is(err, ErrNilSearchValue) {
+		t.Fatal("error MUST be ErrNilSearchValue, found: ", err)
+	}
+
+	if err := store.SearchValueDelete(nil); !errors.Is(err, ErrNilSearchValue) {
+		t.Fatal("error MUST be ErrNilSearchValue, found: ", err)
+	}
+
+	if err := store.SearchValueSoftDelete(nil); !errors.Is(err, ErrNilSearchValue) {
+		t.Fatal("error MUST be ErrNilSearchValue, found: ", err)
+	}
+
+	if err := store.SearchValueCreateMany([]*SearchValue{nil}); !errors.Is(err, ErrNilSearchValue) {
+		t.Fatal("error MUST be ErrNilSearchValue, found: ", err)
+	}
+
+	if _, err := store.Search("value", "fuzzy"); !errors.Is(err, ErrInvalidSearchType) {
+		t.Fatal("error MUST be ErrInvalidSearchType, found: ", err)
+	}
+
+	if _, err := store.SearchAny([]string{"value"}, "fuzzy"); !errors.Is(err, ErrInvalidSearchType) {
+		t.Fatal("error MUST be ErrInvalidSearchType, found: ", err)
+	}
+
+	if _, err := store.SearchCount("value", "fuzzy"); !errors.Is(err, ErrInvalidSearchType) {
+		t.Fatal("error MUST be ErrInvalidSearchType, found: ", err)
+	}
+}
+
+func Test_Store_SearchValueRestore(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_value_restore",
+		AutomigrateEnabled: true,
+		Transformer:        &Sha256Transformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	values := []*SearchValue{
+		NewSearchValue().SetSourceReferenceID("RefId01").SetSearchValue("SearchValue01"),
+		NewSearchValue().SetSourceReferenceID("RefId02").SetSearchValue("SearchValue02"),
+		NewSearchValue().SetSourceReferenceID("RefId02").SetSearchValue("SearchValue03"),
+	}
+
+	err = store.SearchValueCreateMany(values)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.SearchValueSoftDeleteByID(values[0].ID())
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	_, err = store.SearchValueSoftDeleteBySourceReferenceID("RefId02")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	trash, err := store.SearchValueList(SearchValueQueryOptions{OnlyDeleted: true})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(trash) != 3 {
+		t.Fatal("Soft deleted values MUST be 3, found: ", len(trash))
+	}
+
+	err = store.SearchValueRestoreByID(values[0].ID())
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	valueFound, err := store.SearchValueFindByID(values[0].ID())
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if !strings.Contains(valueFound.DeletedAt(), sb.MAX_DATE) {
+		t.Fatal("Deleted at MUST be reset to "+sb.MAX_DATETIME+", found: ", valueFound.DeletedAt())
+	}
+
+	if valueFound.SearchValue() != "ef46c0effb3e3a6d65fbbd46c039008205e67b8089339db1852ca0992804afb9" {
+		t.Fatal("Search value MUST NOT be transformed again, found: ", valueFound.SearchValue())
+	}
+
+	err = store.SearchValueRestoreByID(values[0].ID())
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatal("Restoring a not deleted value MUST return ErrNotFound, found: ", err)
+	}
+
+	restored, err := store.SearchValueRestoreBySourceReferenceID("RefId02")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if restored != 2 {
+		t.Fatal("Restored values MUST be 2, found: ", restored)
+	}
+
+	count, err := store.SearchValueCount(SearchValueQueryOptions{OnlyDeleted: true})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if count != 0 {
+		t.Fatal("Soft deleted values MUST be 0, found: ", count)
+	}
+}
+
+func Test_Store_PurgeSoftDeleted(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_purge_soft_deleted",
+		AutomigrateEnabled: true,
+		Transformer:        &NoChangeTransformer{},
+		BatchSize:          2,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	twoDaysAgo := carbon.Now(carbon.UTC).SubDays(2).ToDateTimeString(carbon.UTC)
+
+	values := []*SearchValue{}
+	for i := 1; i <= 5; i++ {
+		values = append(values, NewSearchValue().
+			SetSourceReferenceID("RefIdOld0"+strconv.Itoa(i)).
+			SetSearchValue("SearchValue0"+strconv.Itoa(i)).
+			SetDeletedAt(twoDaysAgo))
+	}
+
+	values = append(values, NewSearchValue().
+		SetSourceReferenceID("RefIdLive").
+		SetSearchValue("SearchValueLive"))
+
+	values = append(values, NewSearchValue().
+		SetSourceReferenceID("RefIdRecent").
+		SetSearchValue("SearchValueRecent"))
+
+	err = store.SearchValueCreateMany(values)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	_, err = store.SearchValueSoftDeleteBySourceReferenceID("RefIdRecent")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	purged, err := store.PurgeSoftDeleted(24 * time.Hour)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if purged != 5 {
+		t.Fatal("Purged rows MUST be 5, found: ", purged)
+	}
+
+	list, err := store.SearchValueList(SearchValueQueryOptions{WithDeleted: true})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	refIDs := lo.Map(list, func(value SearchValue, index int) string {
+		return value.SourceReferenceID()
+	})
+
+	if len(refIDs) != 2 || !lo.Contains(refIDs, "RefIdLive") || !lo.Contains(refIDs, "RefIdRecent") {
+		t.Fatal("Only the live and recently deleted rows MUST remain, found: ", refIDs)
+	}
+
+	purged, err = store.PurgeSoftDeleted(24 * time.Hour)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if purged != 0 {
+		t.Fatal("Purged rows MUST be 0, found: ", purged)
+	}
+}
+
+func sqliteIndexes(t *testing.T, db *sql.DB, tableName string) []string {
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL ORDER BY name", tableName)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	defer rows.Close()
+
+	names := []string{}
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func Test_Store_AutoMigrateIndexes(t *testing.T) {
+	db := initDB(":memory:")
+
+	tableName := "test_blindindex_indexes"
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          tableName,
+		AutomigrateEnabled: true,
+		Transformer:        &Sha256Transformer{},
+		UniqueSearchValues: true,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	expected := []string{
+		tableName + "_idx_deleted_at",
+		tableName + "_idx_search_value_hash",
+		tableName + "_idx_source_reference_id",
+		tableName + "_uidx_source_reference_id_search_value_hash_deleted_at",
+	}
+
+	indexes := sqliteIndexes(t, db, tableName)
+
+	if strings.Join(indexes, ",") != strings.Join(expected, ",") {
+		t.Fatal("Indexes MUST be ", expected, ", found: ", indexes)
+	}
+
+	// AutoMigrate is idempotent
+	if err := store.AutoMigrate(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	value := NewSearchValue().
+		SetSourceReferenceID("RefId01").
+		SetSearchValue("SearchValue01")
+
+	if err := store.SearchValueCreate(value); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if value.SearchValueHash() != sha256Transform(value.SearchValue()) {
+		t.Fatal("Search value hash MUST be the hash of the transformed value, found: ", value.SearchValueHash())
+	}
+
+	refIDs, err := store.Search("SearchValue01", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefId01" {
+		t.Fatal("Search MUST return [RefId01], found: ", refIDs)
+	}
+
+	err = store.SearchValueCreate(NewSearchValue().
+		SetSourceReferenceID("RefId01").
+		SetSearchValue("SearchValue01"))
+
+	if err == nil {
+		t.Fatal("error MUST NOT be nil for a duplicate entry")
+	}
+
+	err = store.SearchValueCreate(NewSearchValue().
+		SetSourceReferenceID("RefId02").
+		SetSearchValue("SearchValue01"))
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	// a soft deleted entry does not block a new live entry
+	if err := store.SearchValueSoftDelete(value); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.SearchValueCreate(NewSearchValue().
+		SetSourceReferenceID("RefId01").
+		SetSearchValue("SearchValue01"))
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+}
@@ -0,0 +1,629 @@
+// Package blindindexstoretest provides a conformance suite for the
+// implementations of blindindexstore.StoreInterface.
+//
+// The suite can be run against any database (SQLite, MySQL, Postgres)
+// or any other implementation of the store:
+//
+//	func Test_Conformance(t *testing.T) {
+//		blindindexstoretest.RunConformance(t, func(t *testing.T, transformer blindindexstore.TransformerInterface) blindindexstore.StoreInterface {
+//			store, err := blindindexstore.NewStore(blindindexstore.NewStoreOptions{
+//				DB:                 db,
+//				TableName:          "blindindex_" + strconv.FormatInt(time.Now().UnixNano(), 10),
+//				AutomigrateEnabled: true,
+//				Transformer:        transformer,
+//			})
+//
+//			if err != nil {
+//				t.Fatal("unexpected error:", err)
+//			}
+//
+//			return store
+//		})
+//	}
+package blindindexstoretest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/gouniverse/blindindexstore"
+	"github.com/gouniverse/sb"
+)
+
+// StoreFactory returns a new, empty and migrated store using the transformer.
+// It is called once for each test of the suite, so each test must get its
+// own store (e.g. a new table or database).
+type StoreFactory func(t *testing.T, transformer blindindexstore.TransformerInterface) blindindexstore.StoreInterface
+
+// RunConformance runs the conformance suite against the stores
+// returned by the factory
+func RunConformance(t *testing.T, factory StoreFactory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, factory StoreFactory)
+	}{
+		{"Create", testCreate},
+		{"CreateMany", testCreateMany},
+		{"Update", testUpdate},
+		{"Delete", testDelete},
+		{"SoftDelete", testSoftDelete},
+		{"SoftDeleteBySourceReferenceID", testSoftDeleteBySourceReferenceID},
+		{"ReplaceForSourceReference", testReplaceForSourceReference},
+		{"Search", testSearch},
+		{"SearchTransformed", testSearchTransformed},
+		{"SearchFailingVersion", testSearchFailingVersion},
+		{"Filters", testFilters},
+		{"Pagination", testPagination},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, factory)
+		})
+	}
+}
+
+func testCreate(t *testing.T, factory StoreFactory) {
+	store := factory(t, &blindindexstore.NoChangeTransformer{})
+
+	searchValue := blindindexstore.NewSearchValue().
+		SetSourceReferenceID("RefId01").
+		SetSearchValue("john@example.com")
+
+	if err := store.SearchValueCreate(searchValue); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	found, err := store.SearchValueFindByID(searchValue.ID())
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if found.SourceReferenceID() != "RefId01" {
+		t.Fatal("Source reference ID MUST BE 'RefId01', found: ", found.SourceReferenceID())
+	}
+
+	if found.SearchValue() != "john@example.com" {
+		t.Fatal("Search value MUST BE 'john@example.com', found: ", found.SearchValue())
+	}
+
+	found, err = store.SearchValueFindBySourceReferenceID("RefId01")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if found.ID() != searchValue.ID() {
+		t.Fatal("ID MUST BE '"+searchValue.ID()+"', found: ", found.ID())
+	}
+
+	if err := store.SearchValueCreate(nil); !errors.Is(err, blindindexstore.ErrNilSearchValue) {
+		t.Fatal("error MUST BE ErrNilSearchValue, found: ", err)
+	}
+
+	if _, err := store.SearchValueFindByID("missing"); !errors.Is(err, blindindexstore.ErrNotFound) {
+		t.Fatal("error MUST BE ErrNotFound, found: ", err)
+	}
+
+	if _, err := store.SearchValueFindByID(""); !errors.Is(err, blindindexstore.ErrEmptyID) {
+		t.Fatal("error MUST BE ErrEmptyID, found: ", err)
+	}
+
+	if _, err := store.SearchValueFindBySourceReferenceID("missing"); !errors.Is(err, blindindexstore.ErrNotFound) {
+		t.Fatal("error MUST BE ErrNotFound, found: ", err)
+	}
+}
+
+func testCreateMany(t *testing.T, factory StoreFactory) {
+	store := factory(t, &blindindexstore.Rot13Transformer{})
+
+	values := []*blindindexstore.SearchValue{}
+	for i := 1; i <= 3; i++ {
+		values = append(values, blindindexstore.NewSearchValue().
+			SetSourceReferenceID(fmt.Sprintf("RefId%02d", i)).
+			SetSearchValue(fmt.Sprintf("value%02d", i)))
+	}
+
+	if err := store.SearchValueCreateMany(values); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if values[0].SearchValue() != "inyhr01" {
+		t.Fatal("Search value MUST BE transformed to 'inyhr01', found: ", values[0].SearchValue())
+	}
+
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{}, 3)
+
+	invalid := []*blindindexstore.SearchValue{
+		blindindexstore.NewSearchValue().SetSourceReferenceID("RefId04").SetSearchValue("value04"),
+		nil,
+	}
+
+	var createManyError *blindindexstore.CreateManyError
+	if err := store.SearchValueCreateMany(invalid); !errors.As(err, &createManyError) {
+		t.Fatal("error MUST BE a CreateManyError, found: ", err)
+	}
+
+	if createManyError.Failures[1] == nil {
+		t.Fatal("Failure MUST be reported for row 1, found: ", createManyError.Failures)
+	}
+
+	duplicate := []*blindindexstore.SearchValue{
+		blindindexstore.NewSearchValue().SetID("duplicate").SetSourceReferenceID("RefId04").SetSearchValue("value04"),
+		blindindexstore.NewSearchValue().SetID("duplicate").SetSourceReferenceID("RefId05").SetSearchValue("value05"),
+	}
+
+	if err := store.SearchValueCreateMany(duplicate); !errors.Is(err, blindindexstore.ErrDuplicateID) {
+		t.Fatal("error MUST BE ErrDuplicateID, found: ", err)
+	}
+
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{}, 3)
+}
+
+func testUpdate(t *testing.T, factory StoreFactory) {
+	store := factory(t, &blindindexstore.Rot13Transformer{})
+
+	searchValue := blindindexstore.NewSearchValue().
+		SetSourceReferenceID("RefId01").
+		SetSearchValue("john@example.com")
+
+	if err := store.SearchValueCreate(searchValue); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	searchValue.SetSearchValue("jane@example.com")
+
+	if err := store.SearchValueUpdate(searchValue); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	found, err := store.SearchValueFindByID(searchValue.ID())
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if found.SearchValue() != "wnar@rknzcyr.pbz" {
+		t.Fatal("Search value MUST BE transformed to 'wnar@rknzcyr.pbz', found: ", found.SearchValue())
+	}
+
+	assertSearch(t, store, "john@example.com", blindindexstore.SEARCH_TYPE_EQUALS, []string{})
+	assertSearch(t, store, "jane@example.com", blindindexstore.SEARCH_TYPE_EQUALS, []string{"RefId01"})
+
+	if err := store.SearchValueUpdate(nil); !errors.Is(err, blindindexstore.ErrNilSearchValue) {
+		t.Fatal("error MUST BE ErrNilSearchValue, found: ", err)
+	}
+
+	missing := blindindexstore.NewSearchValue().
+		SetSourceReferenceID("RefId02").
+		SetSearchValue("missing@example.com")
+
+	missing.SetSearchValue("other@example.com")
+
+	if err := store.SearchValueUpdate(missing); !errors.Is(err, blindindexstore.ErrNotFound) {
+		t.Fatal("error MUST BE ErrNotFound, found: ", err)
+	}
+
+	// a failed update leaves the value dirty and untransformed
+	if missing.SearchValue() != "other@example.com" {
+		t.Fatal("Search value MUST NOT be transformed on failure, found: ", missing.SearchValue())
+	}
+}
+
+func testDelete(t *testing.T, factory StoreFactory) {
+	store := factory(t, &blindindexstore.NoChangeTransformer{})
+
+	values := createValues(t, store, map[string]string{
+		"RefId01": "john@example.com",
+		"RefId02": "jane@example.com",
+	})
+
+	if err := store.SearchValueDelete(values["RefId01"]); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if _, err := store.SearchValueFindByID(values["RefId01"].ID()); !errors.Is(err, blindindexstore.ErrNotFound) {
+		t.Fatal("error MUST BE ErrNotFound, found: ", err)
+	}
+
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{WithDeleted: true}, 1)
+
+	if err := store.SearchValueDeleteByID(""); !errors.Is(err, blindindexstore.ErrEmptyID) {
+		t.Fatal("error MUST BE ErrEmptyID, found: ", err)
+	}
+
+	if err := store.SearchValueDeleteByID(values["RefId01"].ID()); !errors.Is(err, blindindexstore.ErrNotFound) {
+		t.Fatal("error MUST BE ErrNotFound, found: ", err)
+	}
+
+	if err := store.SearchValueSoftDelete(values["RefId02"]); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	// hard deletes include the soft deleted entries
+	deleted, err := store.SearchValueDeleteBySourceReferenceID("RefId02")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if deleted != 1 {
+		t.Fatal("Deleted MUST BE 1, found: ", deleted)
+	}
+
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{WithDeleted: true}, 0)
+}
+
+func testSoftDelete(t *testing.T, factory StoreFactory) {
+	store := factory(t, &blindindexstore.NoChangeTransformer{})
+
+	values := createValues(t, store, map[string]string{
+		"RefId01": "john@example.com",
+		"RefId02": "jane@example.com",
+	})
+
+	if err := store.SearchValueSoftDeleteByID(values["RefId01"].ID()); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if _, err := store.SearchValueFindByID(values["RefId01"].ID()); !errors.Is(err, blindindexstore.ErrNotFound) {
+		t.Fatal("error MUST BE ErrNotFound, found: ", err)
+	}
+
+	assertSearch(t, store, "john@example.com", blindindexstore.SEARCH_TYPE_EQUALS, []string{})
+	assertSearch(t, store, "example.com", blindindexstore.SEARCH_TYPE_ENDS_WITH, []string{"RefId02"})
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{}, 1)
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{WithDeleted: true}, 2)
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{OnlyDeleted: true}, 1)
+
+	trash, err := store.SearchValueList(blindindexstore.SearchValueQueryOptions{OnlyDeleted: true})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(trash) != 1 || trash[0].ID() != values["RefId01"].ID() {
+		t.Fatal("Trash MUST contain only the soft deleted entry, found: ", trash)
+	}
+
+	if err := store.SearchValueRestoreByID(values["RefId01"].ID()); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	assertSearch(t, store, "john@example.com", blindindexstore.SEARCH_TYPE_EQUALS, []string{"RefId01"})
+
+	if err := store.SearchValueRestoreByID(values["RefId02"].ID()); !errors.Is(err, blindindexstore.ErrNotFound) {
+		t.Fatal("error MUST BE ErrNotFound for an entry which is not deleted, found: ", err)
+	}
+}
+
+func testSoftDeleteBySourceReferenceID(t *testing.T, factory StoreFactory) {
+	store := factory(t, &blindindexstore.NoChangeTransformer{})
+
+	for _, value := range []string{"john@example.com", "john.doe@example.com"} {
+		err := store.SearchValueCreate(blindindexstore.NewSearchValue().
+			SetSourceReferenceID("RefId01").
+			SetSearchValue(value))
+
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+
+	softDeleted, err := store.SearchValueSoftDeleteBySourceReferenceID("RefId01")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if softDeleted != 2 {
+		t.Fatal("Soft deleted MUST BE 2, found: ", softDeleted)
+	}
+
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{}, 0)
+
+	restored, err := store.SearchValueRestoreBySourceReferenceID("RefId01")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if restored != 2 {
+		t.Fatal("Restored MUST BE 2, found: ", restored)
+	}
+
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{}, 2)
+
+	if _, err := store.SearchValueSoftDeleteBySourceReferenceID(""); !errors.Is(err, blindindexstore.ErrEmptyID) {
+		t.Fatal("error MUST BE ErrEmptyID, found: ", err)
+	}
+}
+
+func testReplaceForSourceReference(t *testing.T, factory StoreFactory) {
+	store := factory(t, &blindindexstore.NoChangeTransformer{})
+
+	createValues(t, store, map[string]string{
+		"RefId01": "john@example.com",
+		"RefId02": "jane@example.com",
+	})
+
+	err := store.SearchValueReplaceForSourceReference("RefId01", []string{"john", "doe"})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	assertSearch(t, store, "john@example.com", blindindexstore.SEARCH_TYPE_EQUALS, []string{})
+	assertSearch(t, store, "doe", blindindexstore.SEARCH_TYPE_EQUALS, []string{"RefId01"})
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{SourceReferenceID: "RefId01"}, 2)
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{SourceReferenceID: "RefId02"}, 1)
+}
+
+func testSearch(t *testing.T, factory StoreFactory) {
+	store := factory(t, &blindindexstore.NoChangeTransformer{})
+
+	createValues(t, store, map[string]string{
+		"RefId01": "john@example.com",
+		"RefId02": "jane@example.org",
+		"RefId03": "joe@test.com",
+	})
+
+	assertSearch(t, store, "john@example.com", blindindexstore.SEARCH_TYPE_EQUALS, []string{"RefId01"})
+	assertSearch(t, store, "john@example.com", "", []string{"RefId01"})
+	assertSearch(t, store, "john", blindindexstore.SEARCH_TYPE_EQUALS, []string{})
+	assertSearch(t, store, "example", blindindexstore.SEARCH_TYPE_CONTAINS, []string{"RefId01", "RefId02"})
+	assertSearch(t, store, "jo", blindindexstore.SEARCH_TYPE_STARTS_WITH, []string{"RefId01", "RefId03"})
+	assertSearch(t, store, ".com", blindindexstore.SEARCH_TYPE_ENDS_WITH, []string{"RefId01", "RefId03"})
+	assertSearch(t, store, "example", blindindexstore.SEARCH_TYPE_ENDS_WITH, []string{})
+
+	count, err := store.SearchCount(".com", blindindexstore.SEARCH_TYPE_ENDS_WITH)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if count != 2 {
+		t.Fatal("Count MUST BE 2, found: ", count)
+	}
+
+	refIDs, err := store.SearchAny([]string{"john@example.com", "joe@test.com", "john@example.com", ""}, blindindexstore.SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	assertRefIDs(t, refIDs, []string{"RefId01", "RefId03"})
+
+	if _, err := store.Search("john", "fuzzy"); !errors.Is(err, blindindexstore.ErrInvalidSearchType) {
+		t.Fatal("error MUST BE ErrInvalidSearchType, found: ", err)
+	}
+
+	if _, err := store.SearchAny([]string{"john"}, "fuzzy"); !errors.Is(err, blindindexstore.ErrInvalidSearchType) {
+		t.Fatal("error MUST BE ErrInvalidSearchType, found: ", err)
+	}
+}
+
+func testSearchTransformed(t *testing.T, factory StoreFactory) {
+	store := factory(t, &blindindexstore.Sha256Transformer{})
+
+	values := createValues(t, store, map[string]string{
+		"RefId01": "john@example.com",
+		"RefId02": "jane@example.com",
+	})
+
+	if values["RefId01"].SearchValue() == "john@example.com" {
+		t.Fatal("Search value MUST be transformed on create")
+	}
+
+	assertSearch(t, store, "john@example.com", blindindexstore.SEARCH_TYPE_EQUALS, []string{"RefId01"})
+	assertSearch(t, store, values["RefId01"].SearchValue(), blindindexstore.SEARCH_TYPE_EQUALS, []string{})
+
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{
+		SearchValueIn: []string{"john@example.com", "jane@example.com", "joe@example.com"},
+	}, 2)
+}
+
+// failingVersionTransformer is a versioned transformer,
+// which fails to transform the needles of the searches
+type failingVersionTransformer struct {
+	blindindexstore.NoChangeTransformer
+}
+
+var errVersionUnavailable = errors.New("version unavailable")
+
+func (t *failingVersionTransformer) CurrentVersion() string {
+	return "v1"
+}
+
+func (t *failingVersionTransformer) Versions() []string {
+	return []string{"v1"}
+}
+
+func (t *failingVersionTransformer) TransformVersion(version string, v string) (string, error) {
+	return "", errVersionUnavailable
+}
+
+func testSearchFailingVersion(t *testing.T, factory StoreFactory) {
+	store := factory(t, &failingVersionTransformer{})
+
+	createValues(t, store, map[string]string{
+		"RefId01": "john@example.com",
+		"RefId02": "jane@example.com",
+	})
+
+	// a failing version must fail the search, never match all the rows
+	if _, err := store.Search("nobody", blindindexstore.SEARCH_TYPE_EQUALS); !errors.Is(err, blindindexstore.ErrTransform) || !errors.Is(err, errVersionUnavailable) {
+		t.Fatal("error MUST wrap ErrTransform and the transformer error, found: ", err)
+	}
+
+	if _, err := store.SearchAny([]string{"nobody"}, blindindexstore.SEARCH_TYPE_EQUALS); !errors.Is(err, blindindexstore.ErrTransform) {
+		t.Fatal("error MUST BE ErrTransform, found: ", err)
+	}
+
+	_, err := store.SearchValueCount(blindindexstore.SearchValueQueryOptions{
+		SearchValue: "nobody",
+		SearchType:  blindindexstore.SEARCH_TYPE_EQUALS,
+	})
+
+	if !errors.Is(err, blindindexstore.ErrTransform) {
+		t.Fatal("error MUST BE ErrTransform, found: ", err)
+	}
+
+	_, err = store.SearchValueList(blindindexstore.SearchValueQueryOptions{
+		SearchValueIn: []string{"nobody"},
+	})
+
+	if !errors.Is(err, blindindexstore.ErrTransform) {
+		t.Fatal("error MUST BE ErrTransform, found: ", err)
+	}
+}
+
+func testFilters(t *testing.T, factory StoreFactory) {
+	store := factory(t, &blindindexstore.NoChangeTransformer{})
+
+	values := createValues(t, store, map[string]string{
+		"RefId01": "john@example.com",
+		"RefId02": "jane@example.com",
+		"RefId03": "joe@example.com",
+	})
+
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{ID: values["RefId02"].ID()}, 1)
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{
+		IDIn: []string{values["RefId01"].ID(), values["RefId03"].ID(), "missing"},
+	}, 2)
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{SourceReferenceID: "RefId03"}, 1)
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{
+		SourceReferenceIDIn: []string{"RefId01", "RefId02"},
+	}, 2)
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{
+		SearchValue: "ja",
+		SearchType:  blindindexstore.SEARCH_TYPE_STARTS_WITH,
+	}, 1)
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{
+		SourceReferenceIDIn: []string{"RefId01", "RefId02"},
+		SearchValueIn:       []string{"jane@example.com", "joe@example.com"},
+	}, 1)
+}
+
+func testPagination(t *testing.T, factory StoreFactory) {
+	store := factory(t, &blindindexstore.NoChangeTransformer{})
+
+	for i := 1; i <= 5; i++ {
+		err := store.SearchValueCreate(blindindexstore.NewSearchValue().
+			SetID(fmt.Sprintf("id%02d", i)).
+			SetSourceReferenceID(fmt.Sprintf("RefId%02d", i)).
+			SetSearchValue(fmt.Sprintf("value%02d", i)))
+
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+
+	assertIDs := func(options blindindexstore.SearchValueQueryOptions, expected []string) {
+		t.Helper()
+
+		list, err := store.SearchValueList(options)
+
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+
+		ids := []string{}
+		for _, searchValue := range list {
+			ids = append(ids, searchValue.ID())
+		}
+
+		if !slices.Equal(ids, expected) {
+			t.Fatal("IDs MUST BE ", expected, ", found: ", ids)
+		}
+	}
+
+	assertIDs(blindindexstore.SearchValueQueryOptions{
+		OrderBy:   blindindexstore.COLUMN_ID,
+		SortOrder: sb.ASC,
+		Limit:     2,
+	}, []string{"id01", "id02"})
+
+	assertIDs(blindindexstore.SearchValueQueryOptions{
+		OrderBy:   blindindexstore.COLUMN_ID,
+		SortOrder: sb.ASC,
+		Limit:     2,
+		Offset:    2,
+	}, []string{"id03", "id04"})
+
+	assertIDs(blindindexstore.SearchValueQueryOptions{
+		OrderBy:   blindindexstore.COLUMN_ID,
+		SortOrder: sb.ASC,
+		Limit:     2,
+		Offset:    4,
+	}, []string{"id05"})
+
+	// the sort order defaults to descending
+	assertIDs(blindindexstore.SearchValueQueryOptions{
+		OrderBy: blindindexstore.COLUMN_ID,
+		Limit:   3,
+	}, []string{"id05", "id04", "id03"})
+
+	// the count ignores the pagination
+	assertCount(t, store, blindindexstore.SearchValueQueryOptions{Limit: 2, Offset: 1}, 5)
+}
+
+// createValues creates a search value for each source reference ID
+func createValues(t *testing.T, store blindindexstore.StoreInterface, values map[string]string) map[string]*blindindexstore.SearchValue {
+	t.Helper()
+
+	created := map[string]*blindindexstore.SearchValue{}
+
+	for sourceReferenceID, value := range values {
+		searchValue := blindindexstore.NewSearchValue().
+			SetSourceReferenceID(sourceReferenceID).
+			SetSearchValue(value)
+
+		if err := store.SearchValueCreate(searchValue); err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+
+		created[sourceReferenceID] = searchValue
+	}
+
+	return created
+}
+
+func assertSearch(t *testing.T, store blindindexstore.StoreInterface, needle, searchType string, expected []string) {
+	t.Helper()
+
+	refIDs, err := store.Search(needle, searchType)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	assertRefIDs(t, refIDs, expected)
+}
+
+func assertRefIDs(t *testing.T, refIDs []string, expected []string) {
+	t.Helper()
+
+	refIDs = slices.Sorted(slices.Values(refIDs))
+
+	if !slices.Equal(refIDs, expected) {
+		t.Fatal("Reference IDs MUST BE ", expected, ", found: ", refIDs)
+	}
+}
+
+func assertCount(t *testing.T, store blindindexstore.StoreInterface, options blindindexstore.SearchValueQueryOptions, expected int64) {
+	t.Helper()
+
+	count, err := store.SearchValueCount(options)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if count != expected {
+		t.Fatal("Count MUST BE ", expected, ", found: ", count)
+	}
+}
@@ -1,13 +1,38 @@
 package blindindexstore
 
+const COLUMN_APPLIED_AT = "applied_at"
 const COLUMN_CREATED_AT = "created_at"
 const COLUMN_DELETED_AT = "deleted_at"
 const COLUMN_ID = "id"
+const COLUMN_NGRAM_HASH = "ngram_hash"
 const COLUMN_SOURCE_REFERENCE_ID = "source_reference_id"
 const COLUMN_SEARCH_VALUE = "search_value"
+const COLUMN_SEARCH_VALUE_HASH = "search_value_hash"
+const COLUMN_SEARCH_VALUE_ID = "search_value_id"
+const COLUMN_TRANSFORMER_VERSION = "transformer_version"
 const COLUMN_UPDATED_AT = "updated_at"
 
 const SEARCH_TYPE_EQUALS = "equals"
 const SEARCH_TYPE_CONTAINS = "contains"
 const SEARCH_TYPE_STARTS_WITH = "starts_with"
 const SEARCH_TYPE_ENDS_WITH = "ends_with"
+
+// BATCH_SIZE_DEFAULT is the default number of rows per statement in bulk operations
+const BATCH_SIZE_DEFAULT = 500
+
+// TABLE_SUFFIX_NEXT is the suffix of the shadow table an index is rebuilt in
+const TABLE_SUFFIX_NEXT = "_next"
+
+// TABLE_SUFFIX_PREV is the suffix the replaced live table is kept under
+const TABLE_SUFFIX_PREV = "_prev"
+
+// TABLE_SUFFIX_DROP is the suffix a replaced table is renamed to on MySQL,
+// in the same statement as the swap, before it is dropped
+const TABLE_SUFFIX_DROP = "_drop"
+
+// TABLE_SUFFIX_NGRAMS is the suffix of the table holding the n-grams
+// of the search values, in the n-gram mode
+const TABLE_SUFFIX_NGRAMS = "_ngrams"
+
+// TABLE_SUFFIX_MIGRATIONS is the suffix of the migrations bookkeeping table
+const TABLE_SUFFIX_MIGRATIONS = "_migrations"
@@ -0,0 +1,57 @@
+package blindindexstore
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/mysql"
+	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+	_ "github.com/doug-martin/goqu/v9/dialect/sqlite3"
+	"github.com/gouniverse/sb"
+)
+
+// dialectAliases maps the common database/sql driver names
+// to the dialect names used by the schema builder
+var dialectAliases = map[string]string{
+	"sqlite3":    sb.DIALECT_SQLITE,
+	"pgx":        sb.DIALECT_POSTGRES,
+	"pq":         sb.DIALECT_POSTGRES,
+	"postgresql": sb.DIALECT_POSTGRES,
+	"mariadb":    sb.DIALECT_MYSQL,
+}
+
+// goquDialects maps the schema builder dialects to the query builder dialects
+var goquDialects = map[string]string{
+	sb.DIALECT_SQLITE:   "sqlite3",
+	sb.DIALECT_MYSQL:    "mysql",
+	sb.DIALECT_POSTGRES: "postgres",
+}
+
+// resolveDialect resolves the driver name to one of the supported dialects,
+// all the SQL (DDL and DML) of the store is generated for this dialect.
+// MSSQL is not supported, as the migrations and the table swap are not
+// implemented for T-SQL
+func resolveDialect(driverName string) (string, error) {
+	dialect := strings.ToLower(strings.TrimSpace(driverName))
+
+	if alias, exists := dialectAliases[dialect]; exists {
+		dialect = alias
+	}
+
+	if _, supported := goquDialects[dialect]; !supported {
+		return "", errors.New("blind index store: unsupported driver " + driverName + ", set DbDriverName to one of sqlite, mysql, postgres")
+	}
+
+	return dialect, nil
+}
+
+// sqlBuilder returns the schema builder for the dialect of the store
+func (store *storeImplementation) sqlBuilder() *sb.Builder {
+	return sb.NewBuilder(store.dbDriverName)
+}
+
+// queryBuilder returns the query builder for the dialect of the store
+func (store *storeImplementation) queryBuilder() goqu.DialectWrapper {
+	return goqu.Dialect(goquDialects[store.dbDriverName])
+}
@@ -0,0 +1,196 @@
+package blindindexstore
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gouniverse/sb"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+func Test_ResolveDialect(t *testing.T) {
+	expected := map[string]string{
+		"sqlite":     sb.DIALECT_SQLITE,
+		"sqlite3":    sb.DIALECT_SQLITE,
+		"mysql":      sb.DIALECT_MYSQL,
+		"MariaDB":    sb.DIALECT_MYSQL,
+		"postgres":   sb.DIALECT_POSTGRES,
+		"pgx":        sb.DIALECT_POSTGRES,
+		"postgresql": sb.DIALECT_POSTGRES,
+	}
+
+	for driverName, dialect := range expected {
+		resolved, err := resolveDialect(driverName)
+
+		if err != nil {
+			t.Fatal(driverName, "unexpected error:", err)
+		}
+
+		if resolved != dialect {
+			t.Fatal(driverName, "dialect MUST BE '"+dialect+"', found: ", resolved)
+		}
+	}
+
+	for _, driverName := range []string{"*otelsql.otDriver", "mssql", "sqlserver"} {
+		if _, err := resolveDialect(driverName); err == nil {
+			t.Fatal(driverName, "error MUST NOT be nil for an unsupported driver")
+		}
+	}
+}
+
+func Test_Store_DbDriverNameOverridesDetection(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:           db,
+		DbDriverName: "mysql",
+		TableName:    "test_blindindex_driver_name",
+		Transformer:  &NoChangeTransformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if store.(*storeImplementation).dbDriverName != sb.DIALECT_MYSQL {
+		t.Fatal("dialect MUST BE mysql, found: ", store.(*storeImplementation).dbDriverName)
+	}
+
+	_, err = NewStore(NewStoreOptions{
+		DB:           db,
+		DbDriverName: "oracle",
+		TableName:    "test_blindindex_driver_name",
+		Transformer:  &NoChangeTransformer{},
+	})
+
+	if err == nil {
+		t.Fatal("error MUST NOT be nil for an unsupported driver")
+	}
+}
+
+func Test_Store_GeneratedSQL(t *testing.T) {
+	for _, dialect := range []string{sb.DIALECT_SQLITE, sb.DIALECT_MYSQL, sb.DIALECT_POSTGRES} {
+		t.Run(dialect, func(t *testing.T) {
+			store := &storeImplementation{
+				tableName:          "blindindex",
+				dbDriverName:       dialect,
+				batchSize:          BATCH_SIZE_DEFAULT,
+				uniqueSearchValues: true,
+				ngramSize:          3,
+				transformer:        &NoChangeTransformer{},
+			}
+
+			statements := []string{
+				store.sqlTableCreate(),
+				store.sqlMigrationsTableCreate(),
+			}
+
+			sqlColumnAdd, err := store.sqlColumnAdd(sb.Column{
+				Name:   COLUMN_TRANSFORMER_VERSION,
+				Type:   sb.COLUMN_TYPE_STRING,
+				Length: 40,
+			})
+
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+
+			statements = append(statements, sqlColumnAdd)
+
+			for _, index := range store.tableIndexes() {
+				statements = append(statements, store.sqlIndexCreate(index))
+			}
+
+			statements = append(statements, store.sqlNgramsTableCreate())
+
+			for _, index := range store.ngramsTableIndexes() {
+				statements = append(statements, store.sqlIndexCreate(index))
+			}
+
+			query, err := store.searchValueQuery(context.Background(), SearchValueQueryOptions{
+				SearchValue: "user01@test.com",
+				SearchType:  SEARCH_TYPE_STARTS_WITH,
+				OrderBy:     COLUMN_CREATED_AT,
+				Limit:       10,
+				WithDeleted: true,
+			})
+
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+
+			sqlSelect, _, err := query.ToSQL()
+
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+
+			query, err = store.searchValueQuery(context.Background(), SearchValueQueryOptions{
+				SearchValue: "user01",
+				SearchType:  SEARCH_TYPE_CONTAINS,
+				WithDeleted: true,
+			})
+
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+
+			sqlSelectNgrams, _, err := query.ToSQL()
+
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+
+			sqlInsert, _, err := store.queryBuilder().
+				Insert(store.tableName).
+				Prepared(true).
+				Rows(goqu.Record{COLUMN_ID: "1", COLUMN_SEARCH_VALUE: "user01@test.com"}).
+				ToSQL()
+
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+
+			sqlDelete, _, err := store.queryBuilder().
+				Delete(store.tableName).
+				Where(goqu.C(COLUMN_ID).Eq("1")).
+				ToSQL()
+
+			if err != nil {
+				t.Fatal("unexpected error:", err)
+			}
+
+			statements = append(statements, sqlSelect, sqlSelectNgrams, sqlInsert, sqlDelete)
+
+			assertGolden(t, filepath.Join("testdata", "sql_"+dialect+".golden"), strings.Join(statements, "\n")+"\n")
+		})
+	}
+}
+
+// assertGolden compares the actual output with the golden file,
+// run the tests with -update to regenerate the golden files
+func assertGolden(t *testing.T, path string, actual string) {
+	t.Helper()
+
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+
+	expected, err := os.ReadFile(path)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if string(expected) != actual {
+		t.Fatalf("generated SQL MUST match %s\nexpected:\n%s\nfound:\n%s", path, expected, actual)
+	}
+}
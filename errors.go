@@ -0,0 +1,117 @@
+package blindindexstore
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotFound is returned when the requested search value does not exist
+var ErrNotFound = errors.New("blind index store: search value not found")
+
+// ErrNilSearchValue is returned when a nil search value is passed
+var ErrNilSearchValue = errors.New("blind index store: search value is nil")
+
+// ErrEmptyID is returned when a required ID (or source reference ID) is empty
+var ErrEmptyID = errors.New("blind index store: id is empty")
+
+// ErrDuplicateID is returned when SearchValueCreateMany
+// is passed several search values with the same ID
+var ErrDuplicateID = errors.New("blind index store: id is duplicate")
+
+// ErrInvalidSearchType is returned for an unknown search type
+var ErrInvalidSearchType = errors.New("blind index store: invalid search type")
+
+// ErrUnsupportedSearchType is returned when the transformer
+// does not support the requested search type
+var ErrUnsupportedSearchType = errors.New("blind index store: unsupported search type")
+
+// ErrNeedleTooShort is returned when the needle of a SEARCH_TYPE_CONTAINS
+// search in the n-gram mode is shorter than the n-gram size
+var ErrNeedleTooShort = errors.New("blind index store: needle is shorter than the n-gram size")
+
+// ErrQueryBuild is returned when the SQL query cannot be built
+var ErrQueryBuild = errors.New("blind index store: failed to build query")
+
+// ErrTransform is returned when the transformer fails to transform a value
+var ErrTransform = errors.New("blind index store: failed to transform value")
+
+// queryBuildError wraps the error returned by the query builder
+func queryBuildError(err error) error {
+	return fmt.Errorf("%w: %w", ErrQueryBuild, err)
+}
+
+// transformError wraps the error returned by the transformer
+func transformError(err error) error {
+	return fmt.Errorf("%w: %w", ErrTransform, err)
+}
+
+// duplicateIDError reports the row of the first search value with the same ID
+func duplicateIDError(firstIndex int) error {
+	return fmt.Errorf("%w of row %d", ErrDuplicateID, firstIndex)
+}
+
+// emptySourceReferenceIDError is returned when a required source reference ID is empty
+func emptySourceReferenceIDError() error {
+	return fmt.Errorf("%w: source reference id", ErrEmptyID)
+}
+
+// validateSearchType checks the search type is a known one,
+// an empty search type defaults to SEARCH_TYPE_EQUALS
+func validateSearchType(searchType string) error {
+	switch searchType {
+	case "", SEARCH_TYPE_EQUALS, SEARCH_TYPE_CONTAINS, SEARCH_TYPE_STARTS_WITH, SEARCH_TYPE_ENDS_WITH:
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidSearchType, searchType)
+}
+
+// UnsupportedSearchTypeError is returned when the search type
+// is not one of the search types declared by the transformer
+type UnsupportedSearchTypeError struct {
+	SearchType           string
+	SupportedSearchTypes []string
+}
+
+func (e *UnsupportedSearchTypeError) Error() string {
+	return ErrUnsupportedSearchType.Error() + ": " + e.SearchType + " (supported: " + strings.Join(e.SupportedSearchTypes, ", ") + ")"
+}
+
+// Unwrap allows errors.Is(err, ErrUnsupportedSearchType)
+func (e *UnsupportedSearchTypeError) Unwrap() error {
+	return ErrUnsupportedSearchType
+}
+
+// CreateManyError is returned by SearchValueCreateMany, it reports
+// the failed rows by their index in the input slice. When a batch insert
+// fails, every row of the batch is reported with the same error
+type CreateManyError struct {
+	Failures map[int]error
+}
+
+func (e *CreateManyError) Error() string {
+	indexes := make([]int, 0, len(e.Failures))
+	for index := range e.Failures {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	messages := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		messages = append(messages, "row "+strconv.Itoa(index)+": "+e.Failures[index].Error())
+	}
+
+	return "blind index store: create many failed for " + strconv.Itoa(len(indexes)) + " row(s): " + strings.Join(messages, "; ")
+}
+
+// Unwrap allows errors.Is and errors.As to inspect the row errors
+func (e *CreateManyError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, err := range e.Failures {
+		errs = append(errs, err)
+	}
+	return errs
+}
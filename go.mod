@@ -4,15 +4,19 @@ go 1.23.3
 
 require (
 	github.com/doug-martin/goqu/v9 v9.19.0
+	github.com/go-sql-driver/mysql v1.8.1
+	github.com/gouniverse/base v0.9.0
 	github.com/gouniverse/dataobject v1.2.0
 	github.com/gouniverse/sb v0.8.0
 	github.com/gouniverse/uid v1.5.0
+	github.com/lib/pq v1.10.1
 	github.com/samber/lo v1.49.1
+	golang.org/x/text v0.23.0
 	modernc.org/sqlite v1.37.0
 )
 
 require (
-	github.com/gouniverse/base v0.9.0 // indirect
+	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/spf13/cast v1.7.1 // indirect
 	golang.org/x/exp v0.0.0-20250305212735-054e65f0b394 // indirect
 )
@@ -27,7 +31,6 @@ require (
 	github.com/ncruces/go-strftime v0.1.9 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
 	golang.org/x/sys v0.31.0 // indirect
-	golang.org/x/text v0.23.0 // indirect
 	modernc.org/libc v1.62.1 // indirect
 	modernc.org/mathutil v1.7.1 // indirect
 	modernc.org/memory v1.9.1 // indirect
@@ -1,34 +1,119 @@
 package blindindexstore
 
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
 type StoreInterface interface {
 	AutoMigrate() error
+	AutoMigrateCtx(ctx context.Context) error
+
+	// Migrate applies the pending migrations of the store table
+	Migrate() error
+	MigrateCtx(ctx context.Context) error
+
+	// MigrationStatus returns the migrations of the store table, and whether they are applied
+	MigrationStatus() ([]Migration, error)
+	MigrationStatusCtx(ctx context.Context) ([]Migration, error)
+
+	// PurgeSoftDeleted hard deletes the entries soft deleted more than olderThan ago
+	PurgeSoftDeleted(olderThan time.Duration) (int64, error)
+	PurgeSoftDeletedCtx(ctx context.Context, olderThan time.Duration) (int64, error)
+
+	// Rekey re-transforms the rows of older transformer versions with the current version
+	Rekey(source RekeySourceFunc) (int64, error)
+	RekeyCtx(ctx context.Context, source RekeySourceFunc) (int64, error)
+
+	// RebuildTable builds <table>_next, verifies it and swaps it with the live table
+	RebuildTable(build RebuildTableBuildFunc, opts RebuildTableOptions) error
+	RebuildTableCtx(ctx context.Context, build RebuildTableBuildFunc, opts RebuildTableOptions) error
+
+	// Reindex rebuilds the index from the plaintext values streamed by the source
+	Reindex(ctx context.Context, source ReindexSourceFunc, opts ReindexOptions) (int64, error)
+
+	// RollbackSwap makes <table>_prev the live table again
+	RollbackSwap() error
+	RollbackSwapCtx(ctx context.Context) error
 
 	Search(needle, searchType string) (refIDs []string, err error)
+	SearchCtx(ctx context.Context, needle, searchType string) (refIDs []string, err error)
+	SearchAny(needles []string, searchType string) (refIDs []string, err error)
+	SearchAnyCtx(ctx context.Context, needles []string, searchType string) (refIDs []string, err error)
+	SearchCount(needle, searchType string) (int64, error)
+	SearchCountCtx(ctx context.Context, needle, searchType string) (int64, error)
+	SearchValueCount(options SearchValueQueryOptions) (int64, error)
+	SearchValueCountCtx(ctx context.Context, options SearchValueQueryOptions) (int64, error)
 	SearchValueCreate(value *SearchValue) error
+	SearchValueCreateCtx(ctx context.Context, value *SearchValue) error
+	SearchValueCreateMany(values []*SearchValue) error
+	SearchValueCreateManyCtx(ctx context.Context, values []*SearchValue) error
 	SearchValueDelete(value *SearchValue) error
+	SearchValueDeleteCtx(ctx context.Context, value *SearchValue) error
 	SearchValueDeleteByID(valueID string) error
+	SearchValueDeleteByIDCtx(ctx context.Context, valueID string) error
+	SearchValueDeleteBySourceReferenceID(sourceReferenceID string) (int64, error)
+	SearchValueDeleteBySourceReferenceIDCtx(ctx context.Context, sourceReferenceID string) (int64, error)
 	SearchValueFindByID(id string) (*SearchValue, error)
+	SearchValueFindByIDCtx(ctx context.Context, id string) (*SearchValue, error)
 	SearchValueFindBySourceReferenceID(sourceReferenceID string) (*SearchValue, error)
+	SearchValueFindBySourceReferenceIDCtx(ctx context.Context, sourceReferenceID string) (*SearchValue, error)
 	SearchValueList(options SearchValueQueryOptions) ([]SearchValue, error)
-	SearchValueSoftDelete(discount *SearchValue) error
-	SearchValueSoftDeleteByID(discountID string) error
+	SearchValueListCtx(ctx context.Context, options SearchValueQueryOptions) ([]SearchValue, error)
+	SearchValueReplaceForSourceReference(sourceReferenceID string, values []string) error
+	SearchValueReplaceForSourceReferenceCtx(ctx context.Context, sourceReferenceID string, values []string) error
+	SearchValueRestore(value *SearchValue) error
+	SearchValueRestoreCtx(ctx context.Context, value *SearchValue) error
+	SearchValueRestoreByID(valueID string) error
+	SearchValueRestoreByIDCtx(ctx context.Context, valueID string) error
+	SearchValueRestoreBySourceReferenceID(sourceReferenceID string) (int64, error)
+	SearchValueRestoreBySourceReferenceIDCtx(ctx context.Context, sourceReferenceID string) (int64, error)
+	SearchValueSoftDelete(searchValue *SearchValue) error
+	SearchValueSoftDeleteCtx(ctx context.Context, searchValue *SearchValue) error
+	SearchValueSoftDeleteByID(searchValueID string) error
+	SearchValueSoftDeleteByIDCtx(ctx context.Context, searchValueID string) error
+	SearchValueSoftDeleteBySourceReferenceID(sourceReferenceID string) (int64, error)
+	SearchValueSoftDeleteBySourceReferenceIDCtx(ctx context.Context, sourceReferenceID string) (int64, error)
 	SearchValueUpdate(value *SearchValue) error
+	SearchValueUpdateCtx(ctx context.Context, value *SearchValue) error
+	// SwapTables makes <table>_next the live table, keeping the live table as <table>_prev
+	SwapTables() error
+	SwapTablesCtx(ctx context.Context) error
+
 	Truncate() error
+	TruncateCtx(ctx context.Context) error
 
 	// IsAutomigrateEnabled returns whether automigrate is enabled
 	IsAutomigrateEnabled() bool
+
+	// WithTx returns a store bound to the given transaction
+	WithTx(tx *sql.Tx) StoreInterface
 }
 
+// RekeySourceFunc returns the plaintext of the search value,
+// e.g. by decrypting the source record it references
+type RekeySourceFunc func(searchValue SearchValue) (plaintext string, err error)
+
 type SearchValueQueryOptions struct {
-	ID                string
-	IDIn              string
-	SourceReferenceID string
-	SearchValue       string
-	SearchType        string
-	Offset            int
-	Limit             int
-	SortOrder         string
-	OrderBy           string
-	CountOnly         bool
-	WithDeleted       bool
+	ID                  string
+	IDIn                []string
+	SourceReferenceID   string
+	SourceReferenceIDIn []string
+	SearchValue         string
+	SearchType          string
+
+	// SearchValueIn matches any of the values exactly,
+	// each value is transformed before searching
+	SearchValueIn []string
+
+	Offset      int
+	Limit       int
+	SortOrder   string
+	OrderBy     string
+	CountOnly   bool
+	WithDeleted bool
+
+	// OnlyDeleted lists only the soft deleted entries (the trash)
+	OnlyDeleted bool
 }
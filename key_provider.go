@@ -0,0 +1,106 @@
+package blindindexstore
+
+import (
+	"bytes"
+	"errors"
+	"os"
+)
+
+// KeyProviderInterface supplies the secret key used by keyed transformers
+// (e.g. HmacTransformer). Implementations must never log or expose the key.
+type KeyProviderInterface interface {
+	Key() ([]byte, error)
+}
+
+// == STATIC =================================================================
+
+// StaticKeyProvider provides a key held in memory
+type StaticKeyProvider struct {
+	key []byte
+}
+
+var _ KeyProviderInterface = (*StaticKeyProvider)(nil)
+
+// NewStaticKeyProvider creates a key provider from the given bytes.
+// The bytes are copied, so later changes to the slice have no effect.
+func NewStaticKeyProvider(key []byte) *StaticKeyProvider {
+	return &StaticKeyProvider{key: bytes.Clone(key)}
+}
+
+func (p *StaticKeyProvider) Key() ([]byte, error) {
+	if len(p.key) == 0 {
+		return nil, errors.New("blind index store: static key is empty")
+	}
+
+	return bytes.Clone(p.key), nil
+}
+
+// == ENVIRONMENT VARIABLE ===================================================
+
+// EnvKeyProvider provides a key read from an environment variable
+type EnvKeyProvider struct {
+	Name string
+}
+
+var _ KeyProviderInterface = (*EnvKeyProvider)(nil)
+
+func (p *EnvKeyProvider) Key() ([]byte, error) {
+	if p.Name == "" {
+		return nil, errors.New("blind index store: environment variable name is empty")
+	}
+
+	value, found := os.LookupEnv(p.Name)
+
+	if !found || value == "" {
+		return nil, errors.New("blind index store: environment variable " + p.Name + " is not set")
+	}
+
+	return []byte(value), nil
+}
+
+// == FILE ===================================================================
+
+// FileKeyProvider provides a key read from a file (e.g. a mounted secret).
+// A single trailing newline is ignored.
+type FileKeyProvider struct {
+	Path string
+}
+
+var _ KeyProviderInterface = (*FileKeyProvider)(nil)
+
+func (p *FileKeyProvider) Key() ([]byte, error) {
+	if p.Path == "" {
+		return nil, errors.New("blind index store: key file path is empty")
+	}
+
+	key, err := os.ReadFile(p.Path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	key = bytes.TrimSuffix(key, []byte("\n"))
+	key = bytes.TrimSuffix(key, []byte("\r"))
+
+	if len(key) == 0 {
+		return nil, errors.New("blind index store: key file " + p.Path + " is empty")
+	}
+
+	return key, nil
+}
+
+// == CALLBACK ===============================================================
+
+// KeyProviderFunc adapts a function (e.g. a call to a secrets manager)
+// to the KeyProviderInterface
+type KeyProviderFunc func() ([]byte, error)
+
+var _ KeyProviderInterface = KeyProviderFunc(nil)
+
+func (f KeyProviderFunc) Key() ([]byte, error) {
+	if f == nil {
+		return nil, errors.New("blind index store: key provider func is nil")
+	}
+
+	return f()
+}
@@ -0,0 +1,1408 @@
+package blindindexstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dromara/carbon/v2"
+	"github.com/gouniverse/sb"
+	"github.com/samber/lo"
+)
+
+var _ StoreInterface = (*memoryStore)(nil) // verify it extends the interface
+
+// NewMemoryStoreOptions define the options for creating a new memory store
+type NewMemoryStoreOptions struct {
+	AutomigrateEnabled bool
+	Transformer        TransformerInterface
+
+	// TransformerWithError is used instead of Transformer, for transformers
+	// which can fail (e.g. KMS-backed). Set only one of the two
+	TransformerWithError TransformerWithErrorInterface
+
+	// Normalizers are applied in order to the values before the
+	// transformer, on write and on search
+	Normalizers []NormalizerInterface
+
+	// UniqueSearchValues rejects duplicate (source_reference_id,
+	// search_value_hash, deleted_at) entries, like the unique index of NewStore
+	UniqueSearchValues bool
+
+	// NGramSize enables the n-gram mode, like NewStoreOptions.NGramSize
+	NGramSize int
+}
+
+// rowMatcher is a condition on the rows of the memory store
+type rowMatcher func(row map[string]string) bool
+
+// memoryStore implements StoreInterface in memory, with the same semantics
+// as storeImplementation. It is meant for unit tests, which do not need
+// a database. It is safe for concurrent use.
+type memoryStore struct {
+	mu                   sync.RWMutex
+	rows                 []map[string]string
+	ngrams               map[string][]string // n-gram hashes by search value ID
+	next                 *memoryStore
+	prev                 *memoryStore
+	automigrateEnabled   bool
+	uniqueSearchValues   bool
+	ngramSize            int
+	normalizers          []NormalizerInterface
+	transformer          TransformerInterface
+	transformerWithError TransformerWithErrorInterface
+}
+
+// NewMemoryStore creates a new in-memory store
+func NewMemoryStore(opts NewMemoryStoreOptions) (StoreInterface, error) {
+	if err := validateTransformer(opts.Transformer, opts.TransformerWithError); err != nil {
+		return nil, err
+	}
+
+	if err := validateNormalizers(opts.Normalizers); err != nil {
+		return nil, err
+	}
+
+	if err := validateNgramSize(opts.NGramSize, opts.Transformer, opts.TransformerWithError); err != nil {
+		return nil, err
+	}
+
+	store := &memoryStore{
+		automigrateEnabled:   opts.AutomigrateEnabled,
+		uniqueSearchValues:   opts.UniqueSearchValues,
+		ngramSize:            opts.NGramSize,
+		normalizers:          opts.Normalizers,
+		transformer:          opts.Transformer,
+		transformerWithError: opts.TransformerWithError,
+	}
+
+	return store, nil
+}
+
+// AutoMigrate is a no-op, the memory store has no schema
+func (store *memoryStore) AutoMigrate() error {
+	return store.AutoMigrateCtx(context.Background())
+}
+
+// AutoMigrateCtx is a no-op, the memory store has no schema
+func (store *memoryStore) AutoMigrateCtx(ctx context.Context) error {
+	return store.MigrateCtx(ctx)
+}
+
+// Migrate is a no-op, the memory store has no schema
+func (store *memoryStore) Migrate() error {
+	return store.MigrateCtx(context.Background())
+}
+
+// MigrateCtx is a no-op, the memory store has no schema
+func (store *memoryStore) MigrateCtx(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// MigrationStatus reports all the migration steps as applied
+func (store *memoryStore) MigrationStatus() ([]Migration, error) {
+	return store.MigrationStatusCtx(context.Background())
+}
+
+// MigrationStatusCtx reports all the migration steps as applied
+func (store *memoryStore) MigrationStatusCtx(ctx context.Context) ([]Migration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	migrations := lo.Map(migrationSteps(), func(step migrationStep, index int) Migration {
+		return Migration{
+			ID:          step.id,
+			Description: step.description,
+			Applied:     true,
+		}
+	})
+
+	return migrations, nil
+}
+
+// PurgeSoftDeleted hard deletes the entries soft deleted more than
+// olderThan ago, and returns the number of purged rows
+func (store *memoryStore) PurgeSoftDeleted(olderThan time.Duration) (int64, error) {
+	return store.PurgeSoftDeletedCtx(context.Background(), olderThan)
+}
+
+// PurgeSoftDeletedCtx hard deletes the entries soft deleted more than
+// olderThan ago, and returns the number of purged rows
+func (store *memoryStore) PurgeSoftDeletedCtx(ctx context.Context, olderThan time.Duration) (int64, error) {
+	if olderThan < 0 {
+		olderThan = 0
+	}
+
+	cutoff := carbon.CreateFromStdTime(time.Now().Add(-olderThan), carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	return store.deleteRows(ctx, func(row map[string]string) bool {
+		return row[COLUMN_DELETED_AT] < cutoff
+	})
+}
+
+// Rekey re-transforms the rows created by older versions of the versioned
+// transformer with its current version, and returns the number of re-keyed rows
+func (store *memoryStore) Rekey(source RekeySourceFunc) (int64, error) {
+	return store.RekeyCtx(context.Background(), source)
+}
+
+// RekeyCtx re-transforms the rows created by older versions of the versioned
+// transformer with its current version, and returns the number of re-keyed rows
+func (store *memoryStore) RekeyCtx(ctx context.Context, source RekeySourceFunc) (int64, error) {
+	versioned, isVersioned := store.transformer.(VersionedTransformerInterface)
+
+	if !isVersioned {
+		return 0, errors.New("blind index store: rekey requires a versioned transformer")
+	}
+
+	if source == nil {
+		return 0, errors.New("blind index store: rekey source is required")
+	}
+
+	currentVersion := versioned.CurrentVersion()
+
+	store.mu.RLock()
+	stale := lo.FilterMap(store.rows, func(row map[string]string, index int) (map[string]string, bool) {
+		return maps.Clone(row), row[COLUMN_TRANSFORMER_VERSION] != currentVersion
+	})
+	store.mu.RUnlock()
+
+	slices.SortFunc(stale, func(a, b map[string]string) int {
+		return strings.Compare(a[COLUMN_ID], b[COLUMN_ID])
+	})
+
+	rekeyed := int64(0)
+
+	for _, row := range stale {
+		if err := ctx.Err(); err != nil {
+			return rekeyed, err
+		}
+
+		plaintext, err := source(*NewSearchValueFromExistingData(row))
+
+		if err != nil {
+			return rekeyed, err
+		}
+
+		transformed, err := transformVersion(versioned, currentVersion, normalize(store.normalizers, plaintext))
+
+		if err != nil {
+			return rekeyed, err
+		}
+
+		hashes, err := store.valueNgramHashes(ctx, plaintext)
+
+		if err != nil {
+			return rekeyed, err
+		}
+
+		_, err = store.updateRows(ctx, func(existing map[string]string) bool {
+			return existing[COLUMN_ID] == row[COLUMN_ID]
+		}, map[string]string{
+			COLUMN_SEARCH_VALUE:        transformed,
+			COLUMN_SEARCH_VALUE_HASH:   searchValueHash(transformed),
+			COLUMN_TRANSFORMER_VERSION: currentVersion,
+			COLUMN_UPDATED_AT:          carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC),
+		})
+
+		if err != nil {
+			return rekeyed, err
+		}
+
+		store.ngramsReplace(row[COLUMN_ID], hashes)
+
+		rekeyed++
+	}
+
+	return rekeyed, nil
+}
+
+// RebuildTable builds a complete new table with the build callback,
+// verifies its row count, and swaps it with the live table. Writes to
+// the live table during the build are lost at the swap
+func (store *memoryStore) RebuildTable(build RebuildTableBuildFunc, opts RebuildTableOptions) error {
+	return store.RebuildTableCtx(context.Background(), build, opts)
+}
+
+// RebuildTableCtx builds a complete new table with the build callback,
+// verifies its row count, and swaps it with the live table. Writes to
+// the live table during the build are lost at the swap
+func (store *memoryStore) RebuildTableCtx(ctx context.Context, build RebuildTableBuildFunc, opts RebuildTableOptions) error {
+	if build == nil {
+		return errors.New("blind index store: rebuild build func is required")
+	}
+
+	next := store.emptyTable()
+
+	store.mu.Lock()
+	store.next = next
+	store.mu.Unlock()
+
+	expectedCount, err := build(next)
+
+	if err != nil {
+		return err
+	}
+
+	if err := rebuildTableVerify(ctx, store, next, expectedCount, opts); err != nil {
+		return err
+	}
+
+	return store.SwapTablesCtx(ctx)
+}
+
+// Reindex rebuilds the index from the plaintext values streamed by the
+// source, and returns the number of indexed rows
+func (store *memoryStore) Reindex(ctx context.Context, source ReindexSourceFunc, opts ReindexOptions) (int64, error) {
+	if source == nil {
+		return 0, errors.New("blind index store: reindex source is required")
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize < 1 {
+		batchSize = BATCH_SIZE_DEFAULT
+	}
+
+	if opts.ShadowTable {
+		indexed := int64(0)
+
+		err := store.RebuildTableCtx(ctx, func(next StoreInterface) (int64, error) {
+			var err error
+			indexed, err = next.Reindex(ctx, source, ReindexOptions{BatchSize: batchSize})
+			return indexed, err
+		}, RebuildTableOptions{AllowEmpty: opts.AllowEmpty})
+
+		return indexed, err
+	}
+
+	if opts.ClearExisting {
+		if err := store.TruncateCtx(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	return reindexBatches(ctx, store, source, batchSize)
+}
+
+// RollbackSwap makes the previous table the live table again,
+// and keeps the live table as the next table
+func (store *memoryStore) RollbackSwap() error {
+	return store.RollbackSwapCtx(context.Background())
+}
+
+// RollbackSwapCtx makes the previous table the live table again,
+// and keeps the live table as the next table
+func (store *memoryStore) RollbackSwapCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.prev == nil {
+		return errors.New("blind index store: previous table does not exist")
+	}
+
+	store.prev.mu.Lock()
+	prevRows, prevNgrams := store.prev.rows, store.prev.ngrams
+	store.prev.mu.Unlock()
+
+	store.next = store.emptyTable()
+	store.next.rows, store.next.ngrams = store.rows, store.ngrams
+	store.rows, store.ngrams = prevRows, prevNgrams
+	store.prev = nil
+
+	return nil
+}
+
+func (store *memoryStore) Search(needle, searchType string) (refIDs []string, err error) {
+	return store.SearchCtx(context.Background(), needle, searchType)
+}
+
+func (store *memoryStore) SearchCtx(ctx context.Context, needle, searchType string) (refIDs []string, err error) {
+	list, err := store.SearchValueListCtx(ctx, SearchValueQueryOptions{
+		SearchValue: needle,
+		SearchType:  searchType,
+	})
+
+	if err != nil {
+		return []string{}, err
+	}
+
+	refIDs = lo.Map(list, func(searchValue SearchValue, index int) string {
+		return searchValue.SourceReferenceID()
+	})
+
+	return refIDs, nil
+}
+
+// SearchAny searches for any of the needles,
+// and returns the de-duplicated source reference IDs
+func (store *memoryStore) SearchAny(needles []string, searchType string) (refIDs []string, err error) {
+	return store.SearchAnyCtx(context.Background(), needles, searchType)
+}
+
+// SearchAnyCtx searches for any of the needles,
+// and returns the de-duplicated source reference IDs
+func (store *memoryStore) SearchAnyCtx(ctx context.Context, needles []string, searchType string) (refIDs []string, err error) {
+	if err := store.validateSearchType(searchType); err != nil {
+		return []string{}, err
+	}
+
+	needles = lo.Uniq(lo.Compact(needles))
+
+	if len(needles) == 0 {
+		return []string{}, nil
+	}
+
+	matchesAny, err := store.searchValuesMatcher(ctx, needles, searchType)
+
+	if err != nil {
+		return []string{}, err
+	}
+
+	rows, err := store.selectRows(ctx, SearchValueQueryOptions{}, matchesAny)
+
+	if err != nil {
+		return []string{}, err
+	}
+
+	refIDs = lo.Uniq(lo.Map(rows, func(row map[string]string, index int) string {
+		return row[COLUMN_SOURCE_REFERENCE_ID]
+	}))
+
+	return refIDs, nil
+}
+
+// SearchCount returns the number of entries matching the needle
+func (store *memoryStore) SearchCount(needle, searchType string) (int64, error) {
+	return store.SearchCountCtx(context.Background(), needle, searchType)
+}
+
+// SearchCountCtx returns the number of entries matching the needle
+func (store *memoryStore) SearchCountCtx(ctx context.Context, needle, searchType string) (int64, error) {
+	return store.SearchValueCountCtx(ctx, SearchValueQueryOptions{
+		SearchValue: needle,
+		SearchType:  searchType,
+	})
+}
+
+// SearchValueCount returns the number of entries matching the options
+func (store *memoryStore) SearchValueCount(options SearchValueQueryOptions) (int64, error) {
+	return store.SearchValueCountCtx(context.Background(), options)
+}
+
+// SearchValueCountCtx returns the number of entries matching the options,
+// the pagination options are ignored
+func (store *memoryStore) SearchValueCountCtx(ctx context.Context, options SearchValueQueryOptions) (int64, error) {
+	options.CountOnly = true
+
+	list, err := store.SearchValueListCtx(ctx, options)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(list)), nil
+}
+
+// SearchValueCreate creates the record
+// Side effect! Transforms the value
+func (store *memoryStore) SearchValueCreate(searchValue *SearchValue) error {
+	return store.SearchValueCreateCtx(context.Background(), searchValue)
+}
+
+// SearchValueCreateCtx creates the record
+// Side effect! Transforms the value
+func (store *memoryStore) SearchValueCreateCtx(ctx context.Context, searchValue *SearchValue) error {
+	if searchValue == nil {
+		return ErrNilSearchValue
+	}
+
+	transformed, err := store.transform(ctx, searchValue.SearchValue())
+
+	if err != nil {
+		return err
+	}
+
+	hashes, err := store.valueNgramHashes(ctx, searchValue.SearchValue())
+
+	if err != nil {
+		return err
+	}
+
+	searchValue.SetCreatedAt(carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC))
+	searchValue.SetUpdatedAt(carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC))
+	searchValue.SetSearchValue(transformed)
+	searchValue.SetSearchValueHash(searchValueHash(searchValue.SearchValue()))
+	searchValue.SetTransformerVersion(currentTransformerVersion(store.transformer))
+
+	failures, err := store.insertRows(ctx, []map[string]string{maps.Clone(searchValue.Data())}, map[string][]string{searchValue.ID(): hashes})
+
+	if err != nil {
+		return err
+	}
+
+	if len(failures) > 0 {
+		return failures[0]
+	}
+
+	searchValue.MarkAsNotDirty()
+
+	return nil
+}
+
+// SearchValueCreateMany creates all the records or none of them,
+// on failure a *CreateManyError reports the failed rows.
+// Side effect! Transforms the values, once they are created
+func (store *memoryStore) SearchValueCreateMany(searchValues []*SearchValue) error {
+	return store.SearchValueCreateManyCtx(context.Background(), searchValues)
+}
+
+// SearchValueCreateManyCtx creates all the records or none of them,
+// on failure a *CreateManyError reports the failed rows.
+// Side effect! Transforms the values, once they are created
+func (store *memoryStore) SearchValueCreateManyCtx(ctx context.Context, searchValues []*SearchValue) error {
+	if len(searchValues) == 0 {
+		return nil
+	}
+
+	failures := createManyValidate(searchValues)
+
+	if len(failures) > 0 {
+		return &CreateManyError{Failures: failures}
+	}
+
+	now := carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	rows, err := createManyRows(ctx, searchValues, now, store.transform, currentTransformerVersion(store.transformer))
+
+	if err != nil {
+		return err
+	}
+
+	hashesByID, err := createManyNgramHashes(ctx, searchValues, store.valueNgramHashes)
+
+	if err != nil {
+		return err
+	}
+
+	failures, err = store.insertRows(ctx, rows, hashesByID)
+
+	if err != nil {
+		return err
+	}
+
+	if len(failures) > 0 {
+		return &CreateManyError{Failures: failures}
+	}
+
+	for index, searchValue := range searchValues {
+		searchValue.SetCreatedAt(rows[index][COLUMN_CREATED_AT])
+		searchValue.SetUpdatedAt(rows[index][COLUMN_UPDATED_AT])
+		searchValue.SetSearchValue(rows[index][COLUMN_SEARCH_VALUE])
+		searchValue.SetSearchValueHash(rows[index][COLUMN_SEARCH_VALUE_HASH])
+		searchValue.SetTransformerVersion(rows[index][COLUMN_TRANSFORMER_VERSION])
+		searchValue.MarkAsNotDirty()
+	}
+
+	return nil
+}
+
+func (store *memoryStore) SearchValueDelete(searchValue *SearchValue) error {
+	return store.SearchValueDeleteCtx(context.Background(), searchValue)
+}
+
+func (store *memoryStore) SearchValueDeleteCtx(ctx context.Context, searchValue *SearchValue) error {
+	if searchValue == nil {
+		return ErrNilSearchValue
+	}
+
+	return store.SearchValueDeleteByIDCtx(ctx, searchValue.ID())
+}
+
+func (store *memoryStore) SearchValueDeleteByID(id string) error {
+	return store.SearchValueDeleteByIDCtx(context.Background(), id)
+}
+
+func (store *memoryStore) SearchValueDeleteByIDCtx(ctx context.Context, id string) error {
+	if id == "" {
+		return ErrEmptyID
+	}
+
+	deleted, err := store.deleteRows(ctx, func(row map[string]string) bool {
+		return row[COLUMN_ID] == id
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if deleted < 1 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// SearchValueDeleteBySourceReferenceID hard deletes all the entries
+// (including soft deleted ones) for the source reference, and returns
+// the number of deleted rows
+func (store *memoryStore) SearchValueDeleteBySourceReferenceID(sourceReferenceID string) (int64, error) {
+	return store.SearchValueDeleteBySourceReferenceIDCtx(context.Background(), sourceReferenceID)
+}
+
+// SearchValueDeleteBySourceReferenceIDCtx hard deletes all the entries
+// (including soft deleted ones) for the source reference, and returns
+// the number of deleted rows
+func (store *memoryStore) SearchValueDeleteBySourceReferenceIDCtx(ctx context.Context, sourceReferenceID string) (int64, error) {
+	if sourceReferenceID == "" {
+		return 0, emptySourceReferenceIDError()
+	}
+
+	return store.deleteRows(ctx, func(row map[string]string) bool {
+		return row[COLUMN_SOURCE_REFERENCE_ID] == sourceReferenceID
+	})
+}
+
+func (store *memoryStore) SearchValueFindByID(id string) (*SearchValue, error) {
+	return store.SearchValueFindByIDCtx(context.Background(), id)
+}
+
+func (store *memoryStore) SearchValueFindByIDCtx(ctx context.Context, id string) (*SearchValue, error) {
+	if id == "" {
+		return nil, ErrEmptyID
+	}
+
+	return store.findOne(ctx, SearchValueQueryOptions{
+		ID:    id,
+		Limit: 1,
+	})
+}
+
+func (store *memoryStore) SearchValueFindBySourceReferenceID(sourceReferenceID string) (*SearchValue, error) {
+	return store.SearchValueFindBySourceReferenceIDCtx(context.Background(), sourceReferenceID)
+}
+
+func (store *memoryStore) SearchValueFindBySourceReferenceIDCtx(ctx context.Context, sourceReferenceID string) (*SearchValue, error) {
+	if sourceReferenceID == "" {
+		return nil, emptySourceReferenceIDError()
+	}
+
+	return store.findOne(ctx, SearchValueQueryOptions{
+		SourceReferenceID: sourceReferenceID,
+		Limit:             1,
+	})
+}
+
+func (store *memoryStore) SearchValueList(options SearchValueQueryOptions) ([]SearchValue, error) {
+	return store.SearchValueListCtx(context.Background(), options)
+}
+
+func (store *memoryStore) SearchValueListCtx(ctx context.Context, options SearchValueQueryOptions) ([]SearchValue, error) {
+	if err := store.validateQuerySearchType(options); err != nil {
+		return []SearchValue{}, err
+	}
+
+	rows, err := store.selectRows(ctx, options, nil)
+
+	if err != nil {
+		return []SearchValue{}, err
+	}
+
+	list := lo.Map(rows, func(row map[string]string, index int) SearchValue {
+		return *NewSearchValueFromExistingData(row)
+	})
+
+	return list, nil
+}
+
+// SearchValueReplaceForSourceReference replaces all the index entries for
+// the source reference with the given values, atomically.
+// The existing entries (including soft deleted ones) are hard deleted.
+func (store *memoryStore) SearchValueReplaceForSourceReference(sourceReferenceID string, values []string) error {
+	return store.SearchValueReplaceForSourceReferenceCtx(context.Background(), sourceReferenceID, values)
+}
+
+// SearchValueReplaceForSourceReferenceCtx replaces all the index entries for
+// the source reference with the given values, atomically.
+// The existing entries (including soft deleted ones) are hard deleted.
+func (store *memoryStore) SearchValueReplaceForSourceReferenceCtx(ctx context.Context, sourceReferenceID string, values []string) error {
+	if sourceReferenceID == "" {
+		return emptySourceReferenceIDError()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	replacement := store.emptyTable()
+
+	searchValues := lo.Map(values, func(value string, index int) *SearchValue {
+		return NewSearchValue().
+			SetSourceReferenceID(sourceReferenceID).
+			SetSearchValue(value)
+	})
+
+	if err := replacement.SearchValueCreateManyCtx(ctx, searchValues); err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	rows, replaced := lo.FilterReject(store.rows, func(row map[string]string, index int) bool {
+		return row[COLUMN_SOURCE_REFERENCE_ID] != sourceReferenceID
+	})
+
+	for index, row := range replacement.rows {
+		if err := store.uniqueCheck(rows, row); err != nil {
+			return &CreateManyError{Failures: map[int]error{index: err}}
+		}
+
+		rows = append(rows, row)
+	}
+
+	store.rows = rows
+
+	for _, row := range replaced {
+		delete(store.ngrams, row[COLUMN_ID])
+	}
+
+	for searchValueID, hashes := range replacement.ngrams {
+		store.setNgrams(searchValueID, hashes)
+	}
+
+	return nil
+}
+
+// SearchValueRestore restores a soft deleted record
+func (store *memoryStore) SearchValueRestore(searchValue *SearchValue) error {
+	return store.SearchValueRestoreCtx(context.Background(), searchValue)
+}
+
+// SearchValueRestoreCtx restores a soft deleted record
+func (store *memoryStore) SearchValueRestoreCtx(ctx context.Context, searchValue *SearchValue) error {
+	if searchValue == nil {
+		return ErrNilSearchValue
+	}
+
+	searchValue.SetDeletedAt(sb.MAX_DATETIME)
+
+	return store.SearchValueUpdateCtx(ctx, searchValue)
+}
+
+// SearchValueRestoreByID restores a soft deleted record by ID
+func (store *memoryStore) SearchValueRestoreByID(id string) error {
+	return store.SearchValueRestoreByIDCtx(context.Background(), id)
+}
+
+// SearchValueRestoreByIDCtx restores a soft deleted record by ID
+func (store *memoryStore) SearchValueRestoreByIDCtx(ctx context.Context, id string) error {
+	if id == "" {
+		return ErrEmptyID
+	}
+
+	searchValue, err := store.findOne(ctx, SearchValueQueryOptions{
+		ID:          id,
+		OnlyDeleted: true,
+		Limit:       1,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return store.SearchValueRestoreCtx(ctx, searchValue)
+}
+
+// SearchValueRestoreBySourceReferenceID restores all the soft deleted entries
+// for the source reference, and returns the number of restored rows
+func (store *memoryStore) SearchValueRestoreBySourceReferenceID(sourceReferenceID string) (int64, error) {
+	return store.SearchValueRestoreBySourceReferenceIDCtx(context.Background(), sourceReferenceID)
+}
+
+// SearchValueRestoreBySourceReferenceIDCtx restores all the soft deleted entries
+// for the source reference, and returns the number of restored rows
+func (store *memoryStore) SearchValueRestoreBySourceReferenceIDCtx(ctx context.Context, sourceReferenceID string) (int64, error) {
+	if sourceReferenceID == "" {
+		return 0, emptySourceReferenceIDError()
+	}
+
+	now := carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	return store.updateRows(ctx, func(row map[string]string) bool {
+		return row[COLUMN_SOURCE_REFERENCE_ID] == sourceReferenceID && row[COLUMN_DELETED_AT] <= now
+	}, map[string]string{
+		COLUMN_DELETED_AT: sb.MAX_DATETIME,
+		COLUMN_UPDATED_AT: now,
+	})
+}
+
+func (store *memoryStore) SearchValueSoftDelete(searchValue *SearchValue) error {
+	return store.SearchValueSoftDeleteCtx(context.Background(), searchValue)
+}
+
+func (store *memoryStore) SearchValueSoftDeleteCtx(ctx context.Context, searchValue *SearchValue) error {
+	if searchValue == nil {
+		return ErrNilSearchValue
+	}
+
+	searchValue.SetDeletedAt(carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC))
+
+	return store.SearchValueUpdateCtx(ctx, searchValue)
+}
+
+func (store *memoryStore) SearchValueSoftDeleteByID(id string) error {
+	return store.SearchValueSoftDeleteByIDCtx(context.Background(), id)
+}
+
+func (store *memoryStore) SearchValueSoftDeleteByIDCtx(ctx context.Context, id string) error {
+	searchValue, err := store.SearchValueFindByIDCtx(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	return store.SearchValueSoftDeleteCtx(ctx, searchValue)
+}
+
+// SearchValueSoftDeleteBySourceReferenceID soft deletes all the entries
+// for the source reference, and returns the number of soft deleted rows
+func (store *memoryStore) SearchValueSoftDeleteBySourceReferenceID(sourceReferenceID string) (int64, error) {
+	return store.SearchValueSoftDeleteBySourceReferenceIDCtx(context.Background(), sourceReferenceID)
+}
+
+// SearchValueSoftDeleteBySourceReferenceIDCtx soft deletes all the entries
+// for the source reference, and returns the number of soft deleted rows
+func (store *memoryStore) SearchValueSoftDeleteBySourceReferenceIDCtx(ctx context.Context, sourceReferenceID string) (int64, error) {
+	if sourceReferenceID == "" {
+		return 0, emptySourceReferenceIDError()
+	}
+
+	now := carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)
+
+	return store.updateRows(ctx, func(row map[string]string) bool {
+		return row[COLUMN_SOURCE_REFERENCE_ID] == sourceReferenceID && row[COLUMN_DELETED_AT] > now
+	}, map[string]string{
+		COLUMN_DELETED_AT: now,
+		COLUMN_UPDATED_AT: now,
+	})
+}
+
+// SearchValueUpdate updates the record, or returns ErrNotFound if it does not exist
+// Side effect! Transforms the value, use with caution
+func (store *memoryStore) SearchValueUpdate(searchValue *SearchValue) error {
+	return store.SearchValueUpdateCtx(context.Background(), searchValue)
+}
+
+// SearchValueUpdateCtx updates the record, or returns ErrNotFound if it does not exist
+// Side effect! Transforms the value, use with caution
+func (store *memoryStore) SearchValueUpdateCtx(ctx context.Context, searchValue *SearchValue) error {
+	if searchValue == nil {
+		return ErrNilSearchValue
+	}
+
+	searchValue.SetUpdatedAt(carbon.Now(carbon.UTC).ToDateTimeString())
+
+	dataChanged := searchValue.DataChanged()
+
+	delete(dataChanged, COLUMN_ID) // ID is not updateable
+
+	if len(dataChanged) < 2 {
+		return nil
+	}
+
+	var hashes []string
+
+	if lo.HasKey(dataChanged, COLUMN_SEARCH_VALUE) {
+		transformed, err := store.transform(ctx, searchValue.SearchValue())
+
+		if err != nil {
+			return err
+		}
+
+		hashes, err = store.valueNgramHashes(ctx, searchValue.SearchValue())
+
+		if err != nil {
+			return err
+		}
+
+		dataChanged[COLUMN_SEARCH_VALUE] = transformed
+		dataChanged[COLUMN_SEARCH_VALUE_HASH] = searchValueHash(transformed)
+		dataChanged[COLUMN_TRANSFORMER_VERSION] = currentTransformerVersion(store.transformer)
+	}
+
+	updated, err := store.updateRows(ctx, func(row map[string]string) bool {
+		return row[COLUMN_ID] == searchValue.ID()
+	}, dataChanged)
+
+	if err != nil {
+		return err // the value stays dirty, so the update can be retried
+	}
+
+	if updated < 1 {
+		return ErrNotFound
+	}
+
+	if hashes != nil {
+		store.ngramsReplace(searchValue.ID(), hashes)
+
+		searchValue.SetSearchValue(dataChanged[COLUMN_SEARCH_VALUE])
+		searchValue.SetSearchValueHash(dataChanged[COLUMN_SEARCH_VALUE_HASH])
+		searchValue.SetTransformerVersion(dataChanged[COLUMN_TRANSFORMER_VERSION])
+	}
+
+	searchValue.MarkAsNotDirty()
+
+	return nil
+}
+
+// SwapTables makes the table built by RebuildTable the live table,
+// and keeps the live table as the previous table
+func (store *memoryStore) SwapTables() error {
+	return store.SwapTablesCtx(context.Background())
+}
+
+// SwapTablesCtx makes the table built by RebuildTable the live table,
+// and keeps the live table as the previous table
+func (store *memoryStore) SwapTablesCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if store.next == nil {
+		return errors.New("blind index store: next table does not exist")
+	}
+
+	store.next.mu.Lock()
+	nextRows, nextNgrams := store.next.rows, store.next.ngrams
+	store.next.mu.Unlock()
+
+	store.prev = store.emptyTable()
+	store.prev.rows, store.prev.ngrams = store.rows, store.ngrams
+	store.rows, store.ngrams = nextRows, nextNgrams
+	store.next = nil
+
+	return nil
+}
+
+func (store *memoryStore) Truncate() error {
+	return store.TruncateCtx(context.Background())
+}
+
+func (store *memoryStore) TruncateCtx(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.rows = nil
+	store.ngrams = nil
+
+	return nil
+}
+
+// IsAutomigrateEnabled returns whether automigrate is enabled
+func (store *memoryStore) IsAutomigrateEnabled() bool {
+	return store.automigrateEnabled
+}
+
+// WithTx returns the store itself, the memory store does not support
+// transactions, and the operations are applied immediately
+func (store *memoryStore) WithTx(tx *sql.Tx) StoreInterface {
+	return store
+}
+
+// emptyTable returns a new empty memory store, with the same options
+func (store *memoryStore) emptyTable() *memoryStore {
+	return &memoryStore{
+		automigrateEnabled:   store.automigrateEnabled,
+		uniqueSearchValues:   store.uniqueSearchValues,
+		ngramSize:            store.ngramSize,
+		normalizers:          store.normalizers,
+		transformer:          store.transformer,
+		transformerWithError: store.transformerWithError,
+	}
+}
+
+// validateQuerySearchType checks the search type of the query options,
+// which only applies when a search value is given
+func (store *memoryStore) validateQuerySearchType(options SearchValueQueryOptions) error {
+	if options.SearchValue == "" {
+		return nil
+	}
+
+	return store.validateSearchType(options.SearchType)
+}
+
+// validateSearchType checks the search type is a known one, and is
+// supported by the transformer, or by the n-gram mode for SEARCH_TYPE_CONTAINS
+func (store *memoryStore) validateSearchType(searchType string) error {
+	if searchType == SEARCH_TYPE_CONTAINS && store.ngramSize > 0 {
+		return nil
+	}
+
+	return validateTransformerSearchType(searchType, store.transformer, store.transformerWithError)
+}
+
+// transform normalizes and transforms the plaintext value,
+// with the transformer returning errors if one is set
+func (store *memoryStore) transform(ctx context.Context, v string) (string, error) {
+	return transformValue(ctx, store.transformer, store.transformerWithError, normalize(store.normalizers, v))
+}
+
+// valueNgramHashes returns the n-gram hashes indexed for the plaintext
+// value, like storeImplementation.valueNgramHashes
+func (store *memoryStore) valueNgramHashes(ctx context.Context, plaintext string) ([]string, error) {
+	if store.ngramSize < 1 {
+		return []string{}, nil
+	}
+
+	grams := ngrams(normalize(store.normalizers, plaintext), store.ngramSize)
+
+	return ngramHashes(ctx, grams, func(ctx context.Context, gram string) (string, error) {
+		return transformValue(ctx, store.transformer, store.transformerWithError, gram)
+	})
+}
+
+// ngramsReplace replaces the n-gram hashes of the search value
+func (store *memoryStore) ngramsReplace(searchValueID string, hashes []string) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.setNgrams(searchValueID, hashes)
+}
+
+// setNgrams sets the n-gram hashes of the search value,
+// the caller must hold the write lock
+func (store *memoryStore) setNgrams(searchValueID string, hashes []string) {
+	if len(hashes) == 0 {
+		delete(store.ngrams, searchValueID)
+		return
+	}
+
+	if store.ngrams == nil {
+		store.ngrams = map[string][]string{}
+	}
+
+	store.ngrams[searchValueID] = hashes
+}
+
+// findOne returns the first search value matching the options
+func (store *memoryStore) findOne(ctx context.Context, options SearchValueQueryOptions) (*SearchValue, error) {
+	list, err := store.SearchValueListCtx(ctx, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) < 1 {
+		return nil, ErrNotFound
+	}
+
+	return &list[0], nil
+}
+
+// selectRows returns copies of the rows matching the options, and the
+// optional extra condition, sorted and paginated like searchValueQuery
+func (store *memoryStore) selectRows(ctx context.Context, options SearchValueQueryOptions, extra rowMatcher) ([]map[string]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	matches, err := store.queryMatcher(ctx, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	store.mu.RLock()
+	rows := lo.FilterMap(store.rows, func(row map[string]string, index int) (map[string]string, bool) {
+		if !matches(row) || (extra != nil && !extra(row)) {
+			return nil, false
+		}
+
+		return maps.Clone(row), true
+	})
+	store.mu.RUnlock()
+
+	if options.CountOnly {
+		return rows, nil
+	}
+
+	if options.OrderBy != "" {
+		descending := !strings.EqualFold(options.SortOrder, sb.ASC)
+
+		slices.SortStableFunc(rows, func(a, b map[string]string) int {
+			if descending {
+				return strings.Compare(b[options.OrderBy], a[options.OrderBy])
+			}
+
+			return strings.Compare(a[options.OrderBy], b[options.OrderBy])
+		})
+	}
+
+	if options.Offset > 0 {
+		rows = rows[min(options.Offset, len(rows)):]
+	}
+
+	if options.Limit > 0 {
+		rows = rows[:min(options.Limit, len(rows))]
+	}
+
+	return rows, nil
+}
+
+// queryMatcher returns the condition matching the rows selected by the
+// options, the same conditions as searchValueQuery
+func (store *memoryStore) queryMatcher(ctx context.Context, options SearchValueQueryOptions) (rowMatcher, error) {
+	conditions := []rowMatcher{}
+
+	if options.ID != "" {
+		conditions = append(conditions, func(row map[string]string) bool {
+			return row[COLUMN_ID] == options.ID
+		})
+	}
+
+	if len(options.IDIn) > 0 {
+		conditions = append(conditions, func(row map[string]string) bool {
+			return slices.Contains(options.IDIn, row[COLUMN_ID])
+		})
+	}
+
+	if options.SourceReferenceID != "" {
+		conditions = append(conditions, func(row map[string]string) bool {
+			return row[COLUMN_SOURCE_REFERENCE_ID] == options.SourceReferenceID
+		})
+	}
+
+	if len(options.SourceReferenceIDIn) > 0 {
+		conditions = append(conditions, func(row map[string]string) bool {
+			return slices.Contains(options.SourceReferenceIDIn, row[COLUMN_SOURCE_REFERENCE_ID])
+		})
+	}
+
+	if len(options.SearchValueIn) > 0 {
+		matchesAny, err := store.searchValuesMatcher(ctx, options.SearchValueIn, SEARCH_TYPE_EQUALS)
+
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, matchesAny)
+	}
+
+	if options.SearchValue != "" {
+		matches, err := store.searchValueMatcher(ctx, options.SearchValue, options.SearchType)
+
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, matches)
+	}
+
+	now := carbon.Now(carbon.UTC).ToDateTimeString()
+
+	if options.OnlyDeleted {
+		conditions = append(conditions, func(row map[string]string) bool {
+			return row[COLUMN_DELETED_AT] <= now
+		})
+	} else if !options.WithDeleted {
+		conditions = append(conditions, func(row map[string]string) bool {
+			return row[COLUMN_DELETED_AT] > now
+		})
+	}
+
+	matcher := func(row map[string]string) bool {
+		for _, condition := range conditions {
+			if !condition(row) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return matcher, nil
+}
+
+// searchValuesMatcher returns the condition matching any of the needles
+func (store *memoryStore) searchValuesMatcher(ctx context.Context, needles []string, searchType string) (rowMatcher, error) {
+	matchers := []rowMatcher{}
+
+	for _, needle := range needles {
+		matches, err := store.searchValueMatcher(ctx, needle, searchType)
+
+		if err != nil {
+			return nil, err
+		}
+
+		matchers = append(matchers, matches)
+	}
+
+	matcher := func(row map[string]string) bool {
+		return lo.SomeBy(matchers, func(matches rowMatcher) bool {
+			return matches(row)
+		})
+	}
+
+	return matcher, nil
+}
+
+// searchValueMatcher transforms the needle and returns the condition
+// matching it for the search type, like searchValueExpression
+func (store *memoryStore) searchValueMatcher(ctx context.Context, needle, searchType string) (rowMatcher, error) {
+	if searchType == SEARCH_TYPE_CONTAINS && store.ngramSize > 0 {
+		return store.ngramsMatcher(ctx, needle)
+	}
+
+	versioned, isVersioned := store.transformer.(VersionedTransformerInterface)
+
+	if !isVersioned {
+		transformed, err := store.transform(ctx, needle)
+
+		if err != nil {
+			return nil, err
+		}
+
+		matcher := func(row map[string]string) bool {
+			return searchValueMatches(row[COLUMN_SEARCH_VALUE], transformed, searchType)
+		}
+
+		return matcher, nil
+	}
+
+	versions, err := searchVersions(versioned)
+
+	if err != nil {
+		return nil, err
+	}
+
+	transformedByVersion := map[string]string{}
+
+	for _, version := range versions {
+		transformed, err := transformVersion(versioned, version, normalize(store.normalizers, needle))
+
+		if err != nil {
+			return nil, err
+		}
+
+		transformedByVersion[version] = transformed
+	}
+
+	matcher := func(row map[string]string) bool {
+		transformed, exists := transformedByVersion[row[COLUMN_TRANSFORMER_VERSION]]
+		return exists && searchValueMatches(row[COLUMN_SEARCH_VALUE], transformed, searchType)
+	}
+
+	return matcher, nil
+}
+
+// ngramsMatcher returns the condition matching the search values having
+// all the n-grams of the needle, like ngramsExpression. It reads the
+// n-grams of the store, the rows must be matched holding the lock
+func (store *memoryStore) ngramsMatcher(ctx context.Context, needle string) (rowMatcher, error) {
+	grams, err := needleNgrams(normalize(store.normalizers, needle), store.ngramSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hashesByVersion := map[string][]string{}
+	versioned, isVersioned := store.transformer.(VersionedTransformerInterface)
+
+	if !isVersioned {
+		hashes, err := ngramHashes(ctx, grams, func(ctx context.Context, gram string) (string, error) {
+			return transformValue(ctx, store.transformer, store.transformerWithError, gram)
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		hashesByVersion[""] = hashes
+	} else {
+		versions, err := searchVersions(versioned)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, version := range versions {
+			hashes, err := ngramHashes(ctx, grams, func(ctx context.Context, gram string) (string, error) {
+				return transformVersion(versioned, version, gram)
+			})
+
+			if err != nil {
+				return nil, err
+			}
+
+			hashesByVersion[version] = hashes
+		}
+	}
+
+	matcher := func(row map[string]string) bool {
+		version := ""
+		if isVersioned {
+			version = row[COLUMN_TRANSFORMER_VERSION]
+		}
+
+		hashes, exists := hashesByVersion[version]
+
+		if !exists {
+			return false
+		}
+
+		valueHashes := store.ngrams[row[COLUMN_ID]]
+
+		return lo.EveryBy(hashes, func(hash string) bool {
+			return slices.Contains(valueHashes, hash)
+		})
+	}
+
+	return matcher, nil
+}
+
+// searchValueMatches checks the transformed value matches the already
+// transformed needle for the search type, like searchValueCondition
+func searchValueMatches(value, needle, searchType string) bool {
+	if searchType == SEARCH_TYPE_CONTAINS {
+		return strings.Contains(value, needle)
+	} else if searchType == SEARCH_TYPE_STARTS_WITH {
+		return strings.HasPrefix(value, needle)
+	} else if searchType == SEARCH_TYPE_ENDS_WITH {
+		return strings.HasSuffix(value, needle)
+	}
+
+	return value == needle
+}
+
+// insertRows inserts all the rows, with their n-gram hashes by search
+// value ID, or none of them, and returns the conflicting rows by index
+func (store *memoryStore) insertRows(ctx context.Context, rows []map[string]string, hashesByID map[string][]string) (map[int]error, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	inserted := slices.Clone(store.rows)
+	failures := map[int]error{}
+
+	for index, row := range rows {
+		if err := store.uniqueCheck(inserted, row); err != nil {
+			failures[index] = err
+			continue
+		}
+
+		inserted = append(inserted, row)
+	}
+
+	if len(failures) > 0 {
+		return failures, nil
+	}
+
+	store.rows = inserted
+
+	for searchValueID, hashes := range hashesByID {
+		store.setNgrams(searchValueID, hashes)
+	}
+
+	return nil, nil
+}
+
+// updateRows sets the columns of the rows matching the condition,
+// and returns the number of updated rows
+func (store *memoryStore) updateRows(ctx context.Context, matches rowMatcher, columns map[string]string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	updated := map[int]map[string]string{}
+
+	for index, row := range store.rows {
+		if !matches(row) {
+			continue
+		}
+
+		row = maps.Clone(row)
+		maps.Copy(row, columns)
+
+		others := lo.Reject(store.rows, func(other map[string]string, otherIndex int) bool {
+			return otherIndex == index
+		})
+
+		if err := store.uniqueCheck(others, row); err != nil {
+			return 0, err
+		}
+
+		updated[index] = row
+	}
+
+	for index, row := range updated {
+		store.rows[index] = row
+	}
+
+	return int64(len(updated)), nil
+}
+
+// deleteRows hard deletes the rows matching the condition,
+// and returns the number of deleted rows
+func (store *memoryStore) deleteRows(ctx context.Context, matches rowMatcher) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	count := len(store.rows)
+	store.rows = slices.DeleteFunc(store.rows, func(row map[string]string) bool {
+		if !matches(row) {
+			return false
+		}
+
+		delete(store.ngrams, row[COLUMN_ID])
+
+		return true
+	})
+
+	return int64(count - len(store.rows)), nil
+}
+
+// uniqueCheck checks the row does not conflict with the rows on the
+// primary key, or on the unique index when UniqueSearchValues is enabled
+func (store *memoryStore) uniqueCheck(rows []map[string]string, row map[string]string) error {
+	for _, existing := range rows {
+		if existing[COLUMN_ID] == row[COLUMN_ID] {
+			return errors.New("blind index store: duplicate id " + row[COLUMN_ID])
+		}
+
+		if store.uniqueSearchValues &&
+			existing[COLUMN_SOURCE_REFERENCE_ID] == row[COLUMN_SOURCE_REFERENCE_ID] &&
+			existing[COLUMN_SEARCH_VALUE_HASH] == row[COLUMN_SEARCH_VALUE_HASH] &&
+			existing[COLUMN_DELETED_AT] == row[COLUMN_DELETED_AT] {
+			return errors.New("blind index store: duplicate search value for source reference " + row[COLUMN_SOURCE_REFERENCE_ID])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,268 @@
+package blindindexstore
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func Test_MemoryStore_Concurrency(t *testing.T) {
+	store, err := NewMemoryStore(NewMemoryStoreOptions{
+		Transformer: &Sha256Transformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	wg := sync.WaitGroup{}
+	errs := make(chan error, 100)
+
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+
+			errs <- store.SearchValueCreate(NewSearchValue().
+				SetSourceReferenceID("RefId" + strconv.Itoa(i)).
+				SetSearchValue("user" + strconv.Itoa(i) + "@test.com"))
+		}(i)
+
+		go func(i int) {
+			defer wg.Done()
+
+			_, err := store.Search("user"+strconv.Itoa(i)+"@test.com", SEARCH_TYPE_EQUALS)
+			errs <- err
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+
+	count, err := store.SearchValueCount(SearchValueQueryOptions{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if count != 50 {
+		t.Fatal("Count MUST BE 50, found: ", count)
+	}
+}
+
+func Test_MemoryStore_UniqueSearchValues(t *testing.T) {
+	store, err := NewMemoryStore(NewMemoryStoreOptions{
+		Transformer:        &Sha256Transformer{},
+		UniqueSearchValues: true,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	value := NewSearchValue().
+		SetSourceReferenceID("RefId01").
+		SetSearchValue("john@test.com")
+
+	if err := store.SearchValueCreate(value); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.SearchValueCreate(NewSearchValue().
+		SetSourceReferenceID("RefId01").
+		SetSearchValue("john@test.com"))
+
+	if err == nil {
+		t.Fatal("error MUST NOT be nil for a duplicate search value")
+	}
+
+	err = store.SearchValueCreate(NewSearchValue().
+		SetID(value.ID()).
+		SetSourceReferenceID("RefId02").
+		SetSearchValue("jane@test.com"))
+
+	if err == nil {
+		t.Fatal("error MUST NOT be nil for a duplicate id")
+	}
+
+	err = store.SearchValueCreate(NewSearchValue().
+		SetSourceReferenceID("RefId02").
+		SetSearchValue("john@test.com"))
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	// a soft deleted entry does not block a new live entry
+	if err := store.SearchValueSoftDelete(value); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.SearchValueCreate(NewSearchValue().
+		SetSourceReferenceID("RefId01").
+		SetSearchValue("john@test.com"))
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+}
+
+func Test_MemoryStore_RebuildTable(t *testing.T) {
+	store, err := NewMemoryStore(NewMemoryStoreOptions{
+		Transformer: &NoChangeTransformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.SearchValueCreate(NewSearchValue().
+		SetSourceReferenceID("RefIdOld").
+		SetSearchValue("old@test.com"))
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.RebuildTable(func(next StoreInterface) (int64, error) {
+		return 0, nil
+	}, RebuildTableOptions{})
+
+	if err == nil {
+		t.Fatal("error MUST NOT be nil for empty next table")
+	}
+
+	err = store.RebuildTable(func(next StoreInterface) (int64, error) {
+		return 1, next.SearchValueCreate(NewSearchValue().
+			SetSourceReferenceID("RefIdNew").
+			SetSearchValue("new@test.com"))
+	}, RebuildTableOptions{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	refIDs, err := store.SearchAny([]string{"old@test.com", "new@test.com"}, SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefIdNew" {
+		t.Fatal("Search MUST return [RefIdNew] after the swap, found: ", refIDs)
+	}
+
+	if err := store.RollbackSwap(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	refIDs, err = store.SearchAny([]string{"old@test.com", "new@test.com"}, SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefIdOld" {
+		t.Fatal("Search MUST return [RefIdOld] after the rollback, found: ", refIDs)
+	}
+
+	if err := store.RollbackSwap(); err == nil {
+		t.Fatal("error MUST NOT be nil without a previous table")
+	}
+
+	// the rolled back table becomes the next table
+	if err := store.SwapTables(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	refIDs, err = store.Search("new@test.com", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefIdNew" {
+		t.Fatal("Search MUST return [RefIdNew] after the swap, found: ", refIDs)
+	}
+}
+
+func Test_MemoryStore_Rekey(t *testing.T) {
+	transformer := NewVersionedTransformer()
+
+	if err := transformer.AddVersion("2024", &Rot13Transformer{}); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	store, err := NewMemoryStore(NewMemoryStoreOptions{
+		Transformer: transformer,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.SearchValueCreate(NewSearchValue().
+		SetSourceReferenceID("RefId01").
+		SetSearchValue("john@test.com"))
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if err := transformer.AddVersion("2025", &Sha256Transformer{}); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	refIDs, err := store.Search("john@test.com", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 {
+		t.Fatal("Search MUST find the row of the old version, found: ", refIDs)
+	}
+
+	rekeyed, err := store.Rekey(func(searchValue SearchValue) (string, error) {
+		return "john@test.com", nil
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if rekeyed != 1 {
+		t.Fatal("Rekeyed MUST BE 1, found: ", rekeyed)
+	}
+
+	if err := transformer.RemoveVersion("2024"); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	found, err := store.SearchValueFindBySourceReferenceID("RefId01")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if found.TransformerVersion() != "2025" || found.SearchValue() != sha256Transform("john@test.com") {
+		t.Fatal("Row MUST be re-keyed with version 2025, found: ", found.TransformerVersion(), found.SearchValue())
+	}
+
+	refIDs, err = store.Search("john@test.com", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 {
+		t.Fatal("Search MUST find the re-keyed row, found: ", refIDs)
+	}
+}
@@ -0,0 +1,403 @@
+package blindindexstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hash/fnv"
+	"log"
+	"strconv"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/dromara/carbon/v2"
+	"github.com/gouniverse/base/database"
+	"github.com/gouniverse/sb"
+	"github.com/samber/lo"
+)
+
+// Migration describes a migration step of the store table
+type Migration struct {
+	ID          string
+	Description string
+	Applied     bool
+	AppliedAt   string
+}
+
+// migrationStep is an ordered, idempotent upgrade of the store table.
+// The steps are applied in order, and recorded in the <table>_migrations
+// table once applied.
+type migrationStep struct {
+	id          string
+	description string
+	up          func(ctx context.Context, store *storeImplementation) error
+}
+
+// migrationSteps returns the migration steps in order. Never change or
+// reorder existing steps, append new ones. Each step must be idempotent,
+// as deployments which were created before the migrations existed
+// may already contain some of the changes.
+func migrationSteps() []migrationStep {
+	return []migrationStep{
+		{
+			id:          "0001_create_table",
+			description: "create the baseline table",
+			up: func(ctx context.Context, store *storeImplementation) error {
+				return store.execute(ctx, store.sqlTableCreate())
+			},
+		},
+		{
+			id:          "0002_add_transformer_version",
+			description: "add the transformer_version column",
+			up: func(ctx context.Context, store *storeImplementation) error {
+				return store.addColumnIfMissing(ctx, sb.Column{
+					Name:   COLUMN_TRANSFORMER_VERSION,
+					Type:   sb.COLUMN_TYPE_STRING,
+					Length: 40,
+				})
+			},
+		},
+		{
+			id:          "0003_add_search_value_hash",
+			description: "add and backfill the search_value_hash column",
+			up: func(ctx context.Context, store *storeImplementation) error {
+				err := store.addColumnIfMissing(ctx, sb.Column{
+					Name:   COLUMN_SEARCH_VALUE_HASH,
+					Type:   sb.COLUMN_TYPE_STRING,
+					Length: 64,
+				})
+
+				if err != nil {
+					return err
+				}
+
+				return store.backfillSearchValueHashes(ctx)
+			},
+		},
+		{
+			id:          "0004_create_indexes",
+			description: "create the indexes",
+			up: func(ctx context.Context, store *storeImplementation) error {
+				return store.createIndexes(ctx)
+			},
+		},
+	}
+}
+
+// Migrate applies the pending migration steps
+func (store *storeImplementation) Migrate() error {
+	return store.MigrateCtx(context.Background())
+}
+
+// MigrateCtx applies the pending migration steps. On MySQL and Postgres
+// the instances starting at the same time apply them one after the other
+func (store *storeImplementation) MigrateCtx(ctx context.Context) error {
+	if err := store.execute(ctx, store.sqlMigrationsTableCreate()); err != nil {
+		return err
+	}
+
+	return store.withMigrationLock(ctx, func() error {
+		return store.migrate(ctx)
+	})
+}
+
+// migrate applies the pending migration steps, the status
+// must be read holding the migration lock
+func (store *storeImplementation) migrate(ctx context.Context) error {
+	status, err := store.MigrationStatusCtx(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	steps := migrationSteps()
+
+	for index, migration := range status {
+		if migration.Applied {
+			continue
+		}
+
+		err := store.inTransaction(ctx, func(txStore *storeImplementation) error {
+			if err := steps[index].up(ctx, txStore); err != nil {
+				return err
+			}
+
+			return txStore.migrationRecord(ctx, migration.ID)
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// the schema depending on the options (e.g. UniqueSearchValues,
+	// NGramSize) may be enabled after the migrations were applied
+	return store.createOptionalSchema(ctx)
+}
+
+// migrationLockTimeout is the number of seconds MySQL waits for
+// the migration lock held by another instance
+const migrationLockTimeout = 60
+
+// withMigrationLock runs fn holding a lock on the migrations of the table,
+// so concurrent instances do not apply the same step twice:
+//   - MySQL: GET_LOCK, released by RELEASE_LOCK
+//   - Postgres: pg_advisory_xact_lock, released when its transaction ends
+//   - SQLite: no lock, the database is not shared by concurrent instances
+//
+// The lock holds a connection of the pool while the steps are applied
+// with another one
+func (store *storeImplementation) withMigrationLock(ctx context.Context, fn func() error) error {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(store.migrationsTableName()))
+	key := hash.Sum64()
+
+	switch store.dbDriverName {
+	case sb.DIALECT_MYSQL:
+		conn, err := store.db.Conn(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		defer conn.Close()
+
+		name := "blindindexstore_" + strconv.FormatUint(key, 16)
+
+		var locked sql.NullInt64
+
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, migrationLockTimeout).Scan(&locked); err != nil {
+			return err
+		}
+
+		if !locked.Valid || locked.Int64 != 1 {
+			return errors.New("blind index store: timed out waiting for the migration lock")
+		}
+
+		defer func() {
+			var released sql.NullInt64
+			if err := conn.QueryRowContext(context.Background(), "SELECT RELEASE_LOCK(?)", name).Scan(&released); err != nil {
+				log.Println(err)
+			}
+		}()
+
+		return fn()
+	case sb.DIALECT_POSTGRES:
+		tx, err := store.db.BeginTx(ctx, nil)
+
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+				log.Println(err)
+			}
+		}()
+
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", int64(key)); err != nil {
+			return err
+		}
+
+		return fn()
+	}
+
+	return fn()
+}
+
+// MigrationStatus returns all the migration steps, and whether they are applied
+func (store *storeImplementation) MigrationStatus() ([]Migration, error) {
+	return store.MigrationStatusCtx(context.Background())
+}
+
+// MigrationStatusCtx returns all the migration steps, and whether they are applied.
+// All the steps are pending before the migrations table exists
+func (store *storeImplementation) MigrationStatusCtx(ctx context.Context) ([]Migration, error) {
+	rows, err := store.migrationRows(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAt := lo.SliceToMap(rows, func(row map[string]string) (string, string) {
+		return row[COLUMN_ID], row[COLUMN_APPLIED_AT]
+	})
+
+	migrations := lo.Map(migrationSteps(), func(step migrationStep, index int) Migration {
+		at, applied := appliedAt[step.id]
+
+		return Migration{
+			ID:          step.id,
+			Description: step.description,
+			Applied:     applied,
+			AppliedAt:   at,
+		}
+	})
+
+	return migrations, nil
+}
+
+// migrationRows returns the rows of the migrations table, none if the
+// table does not exist yet (e.g. on a new or a pre-migrations database)
+func (store *storeImplementation) migrationRows(ctx context.Context) ([]map[string]string, error) {
+	exists, err := store.tableExists(ctx, store.migrationsTableName())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return []map[string]string{}, nil
+	}
+
+	sqlStr, _, errSql := store.queryBuilder().
+		From(store.migrationsTableName()).
+		ToSQL()
+
+	if errSql != nil {
+		return nil, queryBuildError(errSql)
+	}
+
+	if store.debugEnabled {
+		log.Println(sqlStr)
+	}
+
+	return database.SelectToMapString(store.toQueryableContext(ctx), sqlStr)
+}
+
+// createTable creates the table with the latest schema, without recording
+// the migrations (e.g. for the shadow table of a rebuild)
+func (store *storeImplementation) createTable(ctx context.Context) error {
+	for _, step := range migrationSteps() {
+		if err := step.up(ctx, store); err != nil {
+			return err
+		}
+	}
+
+	return store.createOptionalSchema(ctx)
+}
+
+// createOptionalSchema creates the missing indexes, and the n-grams table
+// in the n-gram mode only, so the stores without it do not get the table
+func (store *storeImplementation) createOptionalSchema(ctx context.Context) error {
+	if err := store.createIndexes(ctx); err != nil {
+		return err
+	}
+
+	if store.ngramSize < 1 {
+		return nil
+	}
+
+	return store.createNgramsTable(ctx)
+}
+
+// migrationsTableName returns the name of the migrations bookkeeping table
+func (store *storeImplementation) migrationsTableName() string {
+	return store.tableName + TABLE_SUFFIX_MIGRATIONS
+}
+
+// migrationRecord records the migration step as applied
+func (store *storeImplementation) migrationRecord(ctx context.Context, migrationID string) error {
+	sqlStr, params, errSql := store.queryBuilder().
+		Insert(store.migrationsTableName()).
+		Prepared(true).
+		Rows(goqu.Record{
+			COLUMN_ID:         migrationID,
+			COLUMN_APPLIED_AT: carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC),
+		}).
+		ToSQL()
+
+	if errSql != nil {
+		return queryBuildError(errSql)
+	}
+
+	if store.debugEnabled {
+		log.Println(sqlStr)
+	}
+
+	_, err := database.Execute(store.toQueryableContext(ctx), sqlStr, params...)
+
+	return err
+}
+
+// addColumnIfMissing adds the column, unless it already exists
+func (store *storeImplementation) addColumnIfMissing(ctx context.Context, column sb.Column) error {
+	exists, err := store.columnExists(ctx, column.Name)
+
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+
+	sqlStr, err := store.sqlColumnAdd(column)
+
+	if err != nil {
+		return err
+	}
+
+	return store.execute(ctx, sqlStr)
+}
+
+// backfillSearchValueHashes sets the hash of the rows missing one, in batches
+func (store *storeImplementation) backfillSearchValueHashes(ctx context.Context) error {
+	for {
+		sqlStr, _, errSql := store.queryBuilder().
+			From(store.tableName).
+			Select(goqu.C(COLUMN_ID), goqu.C(COLUMN_SEARCH_VALUE)).
+			Where(goqu.C(COLUMN_SEARCH_VALUE_HASH).Eq("")).
+			Limit(uint(store.batchSize)).
+			ToSQL()
+
+		if errSql != nil {
+			return queryBuildError(errSql)
+		}
+
+		if store.debugEnabled {
+			log.Println(sqlStr)
+		}
+
+		rows, err := database.SelectToMapString(store.toQueryableContext(ctx), sqlStr)
+
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			sqlStr, params, errSql := store.queryBuilder().
+				Update(store.tableName).
+				Prepared(true).
+				Set(goqu.Record{COLUMN_SEARCH_VALUE_HASH: searchValueHash(row[COLUMN_SEARCH_VALUE])}).
+				Where(goqu.C(COLUMN_ID).Eq(row[COLUMN_ID])).
+				ToSQL()
+
+			if errSql != nil {
+				return queryBuildError(errSql)
+			}
+
+			if store.debugEnabled {
+				log.Println(sqlStr)
+			}
+
+			if _, err := database.Execute(store.toQueryableContext(ctx), sqlStr, params...); err != nil {
+				return err
+			}
+		}
+
+		if len(rows) < store.batchSize {
+			return nil
+		}
+	}
+}
+
+// execute executes the SQL statement
+func (store *storeImplementation) execute(ctx context.Context, sqlStr string) error {
+	if store.debugEnabled {
+		log.Println(sqlStr)
+	}
+
+	_, err := database.Execute(store.toQueryableContext(ctx), sqlStr)
+
+	return err
+}
@@ -0,0 +1,137 @@
+package blindindexstore
+
+import (
+	"testing"
+)
+
+func Test_Store_Migrate_FromBaseline(t *testing.T) {
+	db := initDB(":memory:")
+
+	tableName := "test_blindindex_migrate_from_baseline"
+
+	// A table created before the migrations existed
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS "` + tableName + `"("id" TEXT(40) PRIMARY KEY NOT NULL, "source_reference_id" TEXT(40) NOT NULL, "search_value" TEXT NOT NULL, "created_at" DATETIME NOT NULL, "updated_at" DATETIME NOT NULL, "deleted_at" DATETIME NOT NULL);`)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO "`+tableName+`" VALUES (?, ?, ?, ?, ?, ?)`,
+		"ID01", "RefId01", "SearchValue01", "2024-01-01 00:00:00", "2024-01-01 00:00:00", "9999-12-31 23:59:59")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          tableName,
+		AutomigrateEnabled: false,
+		Transformer:        &NoChangeTransformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	migrations, err := store.MigrationStatus()
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(migrations) != len(migrationSteps()) {
+		t.Fatal("Migrations MUST list all the steps, found: ", migrations)
+	}
+
+	// all the steps are pending before the migrations table exists
+	for _, migration := range migrations {
+		if migration.Applied {
+			t.Fatal("Migration MUST be pending: ", migration.ID)
+		}
+	}
+
+	if err := store.Migrate(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	migrations, err = store.MigrationStatus()
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(migrations) != len(migrationSteps()) {
+		t.Fatal("Migrations MUST list all the steps, found: ", migrations)
+	}
+
+	for _, migration := range migrations {
+		if !migration.Applied || migration.AppliedAt == "" {
+			t.Fatal("Migration MUST be applied: ", migration.ID)
+		}
+	}
+
+	// Migrating again is a no-op
+	if err := store.Migrate(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	valueFound, err := store.SearchValueFindByID("ID01")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if valueFound.SearchValueHash() != searchValueHash("SearchValue01") {
+		t.Fatal("Search value hash MUST be backfilled, found: ", valueFound.SearchValueHash())
+	}
+
+	refIDs, err := store.Search("SearchValue01", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefId01" {
+		t.Fatal("Search MUST return [RefId01], found: ", refIDs)
+	}
+
+	if len(sqliteIndexes(t, db, tableName)) != 3 {
+		t.Fatal("Indexes MUST be created, found: ", sqliteIndexes(t, db, tableName))
+	}
+}
+
+func Test_Store_Migrate_Fresh(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_migrate_fresh",
+		AutomigrateEnabled: true,
+		Transformer:        &NoChangeTransformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	migrations, err := store.MigrationStatus()
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	for _, migration := range migrations {
+		if !migration.Applied {
+			t.Fatal("Migration MUST be applied: ", migration.ID)
+		}
+	}
+
+	err = store.SearchValueCreate(NewSearchValue().
+		SetSourceReferenceID("RefId01").
+		SetSearchValue("SearchValue01"))
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+}
@@ -9,12 +9,17 @@ import (
 // NewStore creates a new entity store
 func NewStore(opts NewStoreOptions) (StoreInterface, error) {
 	store := &storeImplementation{
-		tableName:          opts.TableName,
-		automigrateEnabled: opts.AutomigrateEnabled,
-		db:                 opts.DB,
-		dbDriverName:       opts.DbDriverName,
-		debugEnabled:       opts.DebugEnabled,
-		transformer:        opts.Transformer,
+		tableName:            opts.TableName,
+		automigrateEnabled:   opts.AutomigrateEnabled,
+		db:                   opts.DB,
+		dbDriverName:         opts.DbDriverName,
+		debugEnabled:         opts.DebugEnabled,
+		batchSize:            opts.BatchSize,
+		uniqueSearchValues:   opts.UniqueSearchValues,
+		ngramSize:            opts.NGramSize,
+		normalizers:          opts.Normalizers,
+		transformer:          opts.Transformer,
+		transformerWithError: opts.TransformerWithError,
 	}
 
 	if store.tableName == "" {
@@ -25,14 +30,34 @@ func NewStore(opts NewStoreOptions) (StoreInterface, error) {
 		return nil, errors.New("blind index store: DB is required")
 	}
 
-	if store.transformer == nil {
-		return nil, errors.New("blind index store: Transformer is required")
+	if err := validateTransformer(store.transformer, store.transformerWithError); err != nil {
+		return nil, err
+	}
+
+	if err := validateNormalizers(store.normalizers); err != nil {
+		return nil, err
+	}
+
+	if err := validateNgramSize(store.ngramSize, store.transformer, store.transformerWithError); err != nil {
+		return nil, err
+	}
+
+	if store.batchSize < 1 {
+		store.batchSize = BATCH_SIZE_DEFAULT
 	}
 
 	if store.dbDriverName == "" {
 		store.dbDriverName = sb.DatabaseDriverName(store.db)
 	}
 
+	dialect, err := resolveDialect(store.dbDriverName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	store.dbDriverName = dialect
+
 	if store.automigrateEnabled {
 		err := store.AutoMigrate()
 
@@ -43,3 +68,50 @@ func NewStore(opts NewStoreOptions) (StoreInterface, error) {
 
 	return store, nil
 }
+
+// validateTransformer checks exactly one of the transformers is set,
+// and that it is usable by a store
+func validateTransformer(transformer TransformerInterface, transformerWithError TransformerWithErrorInterface) error {
+	if transformer != nil && transformerWithError != nil {
+		return errors.New("blind index store: set either Transformer or TransformerWithError, not both")
+	}
+
+	if transformerWithError != nil {
+		return nil
+	}
+
+	if transformer == nil {
+		return errors.New("blind index store: Transformer is required")
+	}
+
+	if versioned, isVersioned := transformer.(VersionedTransformerInterface); isVersioned && len(versioned.Versions()) == 0 {
+		return errors.New("blind index store: Transformer has no versions")
+	}
+
+	return nil
+}
+
+// validateNgramSize checks the n-gram size is not negative, and that
+// the n-gram mode is only enabled with a keyed transformer
+func validateNgramSize(ngramSize int, transformer TransformerInterface, transformerWithError TransformerWithErrorInterface) error {
+	if ngramSize < 0 {
+		return errors.New("blind index store: NGramSize must not be negative")
+	}
+
+	if ngramSize > 0 && !isKeyedTransformer(transformer) && !isKeyedTransformer(transformerWithError) {
+		return errors.New("blind index store: NGramSize requires a keyed transformer (e.g. HmacTransformer), the n-grams hashed without a key are reversible")
+	}
+
+	return nil
+}
+
+// validateNormalizers checks none of the normalizers is nil
+func validateNormalizers(normalizers []NormalizerInterface) error {
+	for _, normalizer := range normalizers {
+		if normalizer == nil {
+			return errors.New("blind index store: Normalizers must not contain nil")
+		}
+	}
+
+	return nil
+}
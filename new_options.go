@@ -4,10 +4,39 @@ import "database/sql"
 
 // NewStoreOptions define the options for creating a new session store
 type NewStoreOptions struct {
-	TableName          string
-	DB                 *sql.DB
-	DbDriverName       string
+	TableName string
+	DB        *sql.DB
+
+	// DbDriverName is the SQL dialect (sqlite, mysql or postgres), detected
+	// from DB if empty. Set it when the driver is wrapped (e.g. instrumented)
+	DbDriverName string
+
 	AutomigrateEnabled bool
 	DebugEnabled       bool
 	Transformer        TransformerInterface
+
+	// TransformerWithError is used instead of Transformer, for transformers
+	// which can fail (e.g. KMS-backed). Set only one of the two
+	TransformerWithError TransformerWithErrorInterface
+
+	// Normalizers are applied in order to the values before the
+	// transformer, on write and on search (e.g. trim and lowercase)
+	Normalizers []NormalizerInterface
+
+	// NGramSize enables the n-gram mode, if greater than 0. Each value is
+	// also indexed as the keyed hashes of its n-grams (of NGramSize runes)
+	// in the <table>_ngrams table, and SEARCH_TYPE_CONTAINS searches return
+	// the values having all the n-grams of the needle. It requires a keyed
+	// transformer (see KeyedTransformerInterface). Changing it requires
+	// a reindex
+	NGramSize int
+
+	// BatchSize is the number of rows per statement used by bulk
+	// operations (e.g. SearchValueCreateMany), defaults to BATCH_SIZE_DEFAULT
+	BatchSize int
+
+	// UniqueSearchValues adds a unique index on (source_reference_id,
+	// search_value_hash, deleted_at) in AutoMigrate, preventing duplicate
+	// live entries. The soft deleted rows do not block new entries
+	UniqueSearchValues bool
 }
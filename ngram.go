@@ -0,0 +1,251 @@
+package blindindexstore
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"unicode/utf8"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/gouniverse/base/database"
+	"github.com/gouniverse/uid"
+	"github.com/samber/lo"
+)
+
+// ngrams returns the distinct n-grams of the value, of size runes each,
+// in order of appearance. A value shorter than size has no n-grams.
+func ngrams(v string, size int) []string {
+	runes := []rune(v)
+	grams := []string{}
+
+	for start := 0; start+size <= len(runes); start++ {
+		grams = append(grams, string(runes[start:start+size]))
+	}
+
+	return lo.Uniq(grams)
+}
+
+// needleNgrams returns the n-grams of the normalized needle of a
+// SEARCH_TYPE_CONTAINS search, the needle must have at least one
+func needleNgrams(needle string, size int) ([]string, error) {
+	if utf8.RuneCountInString(needle) < size {
+		return nil, fmt.Errorf("%w: %d", ErrNeedleTooShort, size)
+	}
+
+	return ngrams(needle, size), nil
+}
+
+// ngramHashes transforms the n-grams, and returns the distinct fixed-length
+// hashes of the transformed n-grams, like searchValueHash
+func ngramHashes(ctx context.Context, grams []string, transform func(ctx context.Context, v string) (string, error)) ([]string, error) {
+	hashes := make([]string, 0, len(grams))
+
+	for _, gram := range grams {
+		transformed, err := transform(ctx, gram)
+
+		if err != nil {
+			return nil, err
+		}
+
+		hashes = append(hashes, searchValueHash(transformed))
+	}
+
+	return lo.Uniq(hashes), nil
+}
+
+// createManyNgramHashes returns the n-gram hashes of the plaintext values
+// passed to SearchValueCreateMany by search value ID. Transformer failures
+// are reported in a *CreateManyError
+func createManyNgramHashes(ctx context.Context, searchValues []*SearchValue, valueNgramHashes func(ctx context.Context, plaintext string) ([]string, error)) (map[string][]string, error) {
+	hashesByID := map[string][]string{}
+	failures := map[int]error{}
+
+	for index, searchValue := range searchValues {
+		hashes, err := valueNgramHashes(ctx, searchValue.SearchValue())
+
+		if err != nil {
+			failures[index] = err
+			continue
+		}
+
+		hashesByID[searchValue.ID()] = hashes
+	}
+
+	if len(failures) > 0 {
+		return nil, &CreateManyError{Failures: failures}
+	}
+
+	return hashesByID, nil
+}
+
+// ngramsTableName returns the name of the n-grams table
+func (store *storeImplementation) ngramsTableName() string {
+	return store.tableName + TABLE_SUFFIX_NGRAMS
+}
+
+// createNgramsTable creates the n-grams table and its indexes
+func (store *storeImplementation) createNgramsTable(ctx context.Context) error {
+	if err := store.execute(ctx, store.sqlNgramsTableCreate()); err != nil {
+		return err
+	}
+
+	return store.createTableIndexes(ctx, store.ngramsTableIndexes())
+}
+
+// valueNgramHashes returns the n-gram hashes indexed for the plaintext
+// value, transformed with the current transformer. There are none if the
+// n-gram mode is disabled
+func (store *storeImplementation) valueNgramHashes(ctx context.Context, plaintext string) ([]string, error) {
+	if store.ngramSize < 1 {
+		return []string{}, nil
+	}
+
+	grams := ngrams(normalize(store.normalizers, plaintext), store.ngramSize)
+
+	return ngramHashes(ctx, grams, func(ctx context.Context, gram string) (string, error) {
+		return transformValue(ctx, store.transformer, store.transformerWithError, gram)
+	})
+}
+
+// ngramsExpression returns the condition matching the search values
+// having all the n-grams of the needle. With a versioned transformer the
+// n-grams are transformed with every active version, and matched against
+// the rows of that version
+func (store *storeImplementation) ngramsExpression(ctx context.Context, needle string) (exp.Expression, error) {
+	grams, err := needleNgrams(normalize(store.normalizers, needle), store.ngramSize)
+
+	if err != nil {
+		return nil, err
+	}
+
+	versioned, isVersioned := store.transformer.(VersionedTransformerInterface)
+
+	if !isVersioned {
+		hashes, err := ngramHashes(ctx, grams, func(ctx context.Context, gram string) (string, error) {
+			return transformValue(ctx, store.transformer, store.transformerWithError, gram)
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		return store.ngramsCondition(hashes), nil
+	}
+
+	versions, err := searchVersions(versioned)
+
+	if err != nil {
+		return nil, err
+	}
+
+	expressions := []exp.Expression{}
+
+	for _, version := range versions {
+		hashes, err := ngramHashes(ctx, grams, func(ctx context.Context, gram string) (string, error) {
+			return transformVersion(versioned, version, gram)
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		expressions = append(expressions, goqu.And(
+			goqu.C(COLUMN_TRANSFORMER_VERSION).Eq(version),
+			store.ngramsCondition(hashes),
+		))
+	}
+
+	return goqu.Or(expressions...), nil
+}
+
+// ngramsCondition returns the condition matching the search values
+// having all the n-gram hashes, by intersecting the n-gram hits
+func (store *storeImplementation) ngramsCondition(hashes []string) exp.Expression {
+	return inSubquery(COLUMN_ID, store.queryBuilder().
+		From(store.ngramsTableName()).
+		Select(goqu.C(COLUMN_SEARCH_VALUE_ID)).
+		Where(goqu.C(COLUMN_NGRAM_HASH).In(hashes)).
+		GroupBy(goqu.C(COLUMN_SEARCH_VALUE_ID)).
+		Having(goqu.COUNT(goqu.DISTINCT(goqu.C(COLUMN_NGRAM_HASH))).Eq(len(hashes))))
+}
+
+// inSubquery returns the condition matching the values of the column
+// returned by the subquery. The In method of goqu wraps a single argument
+// in a list, which makes it a scalar subquery: IN ((SELECT ...))
+func inSubquery(column string, subquery *goqu.SelectDataset) exp.Expression {
+	return exp.NewBooleanExpression(exp.InOp, goqu.C(column), subquery)
+}
+
+// inNgramsTransaction runs fn inside a transaction if the n-gram mode is
+// enabled, so the store table and the n-grams table are written atomically
+func (store *storeImplementation) inNgramsTransaction(ctx context.Context, fn func(txStore *storeImplementation) error) error {
+	if store.ngramSize < 1 {
+		return fn(store)
+	}
+
+	return store.inTransaction(ctx, fn)
+}
+
+// ngramsInsert inserts the n-gram hashes of the search values by search
+// value ID, in batches of multi-row inserts
+func (store *storeImplementation) ngramsInsert(ctx context.Context, hashesByID map[string][]string) error {
+	rows := []map[string]string{}
+
+	for searchValueID, hashes := range hashesByID {
+		for _, hash := range hashes {
+			rows = append(rows, map[string]string{
+				COLUMN_ID:              uid.HumanUid(),
+				COLUMN_SEARCH_VALUE_ID: searchValueID,
+				COLUMN_NGRAM_HASH:      hash,
+			})
+		}
+	}
+
+	ngramsTable := store.withTableName(store.ngramsTableName())
+
+	for start := 0; start < len(rows); start += store.batchSize {
+		end := min(start+store.batchSize, len(rows))
+
+		if err := ngramsTable.insertRows(ctx, rows[start:end]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ngramsReplace replaces the n-gram hashes of the search value
+func (store *storeImplementation) ngramsReplace(ctx context.Context, searchValueID string, hashes []string) error {
+	if err := store.ngramsDelete(ctx, goqu.C(COLUMN_SEARCH_VALUE_ID).Eq(searchValueID)); err != nil {
+		return err
+	}
+
+	return store.ngramsInsert(ctx, map[string][]string{searchValueID: hashes})
+}
+
+// ngramsDelete deletes the n-gram hashes matching the conditions (all of
+// them without conditions), it is a no-op if the n-gram mode is disabled
+func (store *storeImplementation) ngramsDelete(ctx context.Context, where ...exp.Expression) error {
+	if store.ngramSize < 1 {
+		return nil
+	}
+
+	sqlStr, params, errSql := store.queryBuilder().
+		Delete(store.ngramsTableName()).
+		Prepared(true).
+		Where(where...).
+		ToSQL()
+
+	if errSql != nil {
+		return queryBuildError(errSql)
+	}
+
+	if store.debugEnabled {
+		log.Println(sqlStr)
+	}
+
+	_, err := database.Execute(store.toQueryableContext(ctx), sqlStr, params...)
+
+	return err
+}
@@ -0,0 +1,381 @@
+package blindindexstore
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_Ngrams(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []string
+	}{
+		{"hello", []string{"hel", "ell", "llo"}},
+		{"aaaa", []string{"aaa"}},
+		{"josé", []string{"jos", "osé"}},
+		{"ab", []string{}},
+	}
+
+	for _, test := range tests {
+		grams := ngrams(test.value, 3)
+
+		if !slices.Equal(grams, test.expected) {
+			t.Fatal("N-grams of '"+test.value+"' MUST BE ", test.expected, ", found: ", grams)
+		}
+	}
+}
+
+func Test_Store_Ngrams(t *testing.T) {
+	sqlStore, err := NewStore(NewStoreOptions{
+		DB:                 initDB(":memory:"),
+		TableName:          "test_blindindex_ngrams",
+		AutomigrateEnabled: true,
+		Transformer:        ngramTestTransformer(t, "ngram-test-key-01"),
+		Normalizers:        []NormalizerInterface{&LowercaseNormalizer{}},
+		NGramSize:          3,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	memoryStore, err := NewMemoryStore(NewMemoryStoreOptions{
+		Transformer: ngramTestTransformer(t, "ngram-test-key-01"),
+		Normalizers: []NormalizerInterface{&LowercaseNormalizer{}},
+		NGramSize:   3,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	for name, store := range map[string]StoreInterface{"sql": sqlStore, "memory": memoryStore} {
+		john := NewSearchValue().SetSourceReferenceID("RefId01").SetSearchValue("John Smith")
+
+		if err := store.SearchValueCreate(john); err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		err := store.SearchValueCreateMany([]*SearchValue{
+			NewSearchValue().SetSourceReferenceID("RefId02").SetSearchValue("Jane Smithers"),
+			NewSearchValue().SetSourceReferenceID("RefId03").SetSearchValue("Johnny Doe"),
+		})
+
+		if err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		assertNgramSearch(t, name, store, "SMITH", []string{"RefId01", "RefId02"})
+		assertNgramSearch(t, name, store, "john", []string{"RefId01", "RefId03"})
+		assertNgramSearch(t, name, store, "hn sm", []string{"RefId01"})
+		assertNgramSearch(t, name, store, "smithy", []string{})
+
+		// the exact matches use the search values
+		refIDs, err := store.Search("john smith", SEARCH_TYPE_EQUALS)
+
+		if err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		if len(refIDs) != 1 || refIDs[0] != "RefId01" {
+			t.Fatal(name, "Search MUST return [RefId01], found: ", refIDs)
+		}
+
+		if _, err := store.Search("jo", SEARCH_TYPE_CONTAINS); !errors.Is(err, ErrNeedleTooShort) {
+			t.Fatal(name, "error MUST BE ErrNeedleTooShort, found: ", err)
+		}
+
+		if _, err := store.Search("john", SEARCH_TYPE_STARTS_WITH); !errors.Is(err, ErrUnsupportedSearchType) {
+			t.Fatal(name, "error MUST BE ErrUnsupportedSearchType, found: ", err)
+		}
+
+		john.SetSearchValue("Johan Smith")
+
+		if err := store.SearchValueUpdate(john); err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		assertNgramSearch(t, name, store, "john", []string{"RefId03"})
+		assertNgramSearch(t, name, store, "johan", []string{"RefId01"})
+
+		if _, err := store.SearchValueSoftDeleteBySourceReferenceID("RefId02"); err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		assertNgramSearch(t, name, store, "smith", []string{"RefId01"})
+
+		if err := store.SearchValueReplaceForSourceReference("RefId03", []string{"Peter Parker"}); err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		assertNgramSearch(t, name, store, "john", []string{})
+		assertNgramSearch(t, name, store, "parker", []string{"RefId03"})
+
+		count, err := store.SearchValueCount(SearchValueQueryOptions{
+			SearchValue: "park",
+			SearchType:  SEARCH_TYPE_CONTAINS,
+		})
+
+		if err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		if count != 1 {
+			t.Fatal(name, "Count MUST BE 1, found: ", count)
+		}
+	}
+
+	// the n-grams are deleted with their search values
+	assertNgramRowCount(t, sqlStore.(*storeImplementation), 30)
+
+	searchValue, err := sqlStore.SearchValueFindBySourceReferenceID("RefId01")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if err := sqlStore.SearchValueDeleteByID(searchValue.ID()); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	assertNgramRowCount(t, sqlStore.(*storeImplementation), 21)
+
+	if _, err := sqlStore.SearchValueDeleteBySourceReferenceID("RefId02"); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	assertNgramRowCount(t, sqlStore.(*storeImplementation), 10)
+
+	if _, err := NewMemoryStore(NewMemoryStoreOptions{Transformer: &Sha256Transformer{}, NGramSize: -1}); err == nil {
+		t.Fatal("error MUST NOT be nil for a negative n-gram size")
+	}
+
+	// the n-grams hashed without a key are reversible
+	if _, err := NewMemoryStore(NewMemoryStoreOptions{Transformer: &Sha256Transformer{}, NGramSize: 3}); err == nil {
+		t.Fatal("error MUST NOT be nil for an unkeyed transformer")
+	}
+
+	chain, err := NewChainTransformer(TransformerFunc(strings.ToLower), ngramTestTransformer(t, "ngram-test-key-01"))
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if _, err := NewMemoryStore(NewMemoryStoreOptions{Transformer: chain, NGramSize: 3}); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+}
+
+func Test_Store_Ngrams_Versioned(t *testing.T) {
+
+	for _, name := range []string{"sql", "memory"} {
+		transformer := &failingVersionedTransformer{VersionedTransformer: NewVersionedTransformer()}
+
+		if err := transformer.AddVersion("2025", ngramTestTransformer(t, "ngram-test-key-2025")); err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		var store StoreInterface
+		var err error
+
+		if name == "sql" {
+			store, err = NewStore(NewStoreOptions{
+				DB:                 initDB(":memory:"),
+				TableName:          "test_blindindex_ngrams_versioned",
+				AutomigrateEnabled: true,
+				Transformer:        transformer,
+				NGramSize:          3,
+			})
+		} else {
+			store, err = NewMemoryStore(NewMemoryStoreOptions{
+				Transformer: transformer,
+				NGramSize:   3,
+			})
+		}
+
+		if err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		if err := store.SearchValueCreate(NewSearchValue().SetSourceReferenceID("RefId01").SetSearchValue("john smith")); err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		if err := transformer.AddVersion("2026", ngramTestTransformer(t, "ngram-test-key-2026")); err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		if err := store.SearchValueCreate(NewSearchValue().SetSourceReferenceID("RefId02").SetSearchValue("jane smith")); err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		assertNgramSearch(t, name, store, "smith", []string{"RefId01", "RefId02"})
+
+		rekeyed, err := store.Rekey(func(searchValue SearchValue) (string, error) {
+			return "john smith", nil
+		})
+
+		if err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		if rekeyed != 1 {
+			t.Fatal(name, "Rekeyed MUST BE 1, found: ", rekeyed)
+		}
+
+		if err := transformer.RemoveVersion("2025"); err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		assertNgramSearch(t, name, store, "smith", []string{"RefId01", "RefId02"})
+		assertNgramSearch(t, name, store, "john", []string{"RefId01"})
+
+		// a failing version must fail the search, never match all the rows
+		transformer.failing = true
+
+		if _, err := store.Search("nobody", SEARCH_TYPE_CONTAINS); !errors.Is(err, ErrTransform) {
+			t.Fatal(name, "error MUST BE ErrTransform, found: ", err)
+		}
+	}
+}
+
+// failingVersionedTransformer is a versioned transformer,
+// which fails to transform with a version once failing is set
+type failingVersionedTransformer struct {
+	*VersionedTransformer
+	failing bool
+}
+
+func (t *failingVersionedTransformer) TransformVersion(version string, v string) (string, error) {
+	if t.failing {
+		return "", errors.New("version unavailable")
+	}
+
+	return t.VersionedTransformer.TransformVersion(version, v)
+}
+
+func Test_Store_Ngrams_ReindexShadowTable(t *testing.T) {
+	store, err := NewStore(NewStoreOptions{
+		DB:                 initDB(":memory:"),
+		TableName:          "test_blindindex_ngrams_reindex",
+		AutomigrateEnabled: true,
+		Transformer:        ngramTestTransformer(t, "ngram-test-key-01"),
+		NGramSize:          3,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if err := store.SearchValueCreate(NewSearchValue().SetSourceReferenceID("RefId01").SetSearchValue("old value")); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	for _, round := range []string{"first", "second"} {
+		_, err = store.Reindex(context.Background(), func(yield func(sourceReferenceID, plaintext string) bool) error {
+			yield("RefId02", "john smith")
+			yield("RefId03", "jane smith")
+			return nil
+		}, ReindexOptions{ShadowTable: true})
+
+		if err != nil {
+			t.Fatal(round, "unexpected error:", err)
+		}
+
+		assertNgramSearch(t, round, store, "smith", []string{"RefId02", "RefId03"})
+		assertNgramSearch(t, round, store, "old", []string{})
+	}
+
+	if err := store.RollbackSwap(); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	assertNgramSearch(t, "rollback", store, "smith", []string{"RefId02", "RefId03"})
+}
+
+func Test_Store_Ngrams_TableCreatedInNgramModeOnly(t *testing.T) {
+	db := initDB(":memory:")
+
+	for _, ngramSize := range []int{0, 3} {
+		_, err := NewStore(NewStoreOptions{
+			DB:                 db,
+			TableName:          "test_blindindex_ngrams_" + strconv.Itoa(ngramSize),
+			AutomigrateEnabled: true,
+			Transformer:        ngramTestTransformer(t, "ngram-test-key-01"),
+			NGramSize:          ngramSize,
+		})
+
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+	}
+
+	var count int
+
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name LIKE ?", "%"+TABLE_SUFFIX_NGRAMS).Scan(&count)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if count != 1 {
+		t.Fatal("N-grams tables MUST BE 1, found: ", count)
+	}
+
+	indexes := sqliteIndexes(t, db, "test_blindindex_ngrams_3"+TABLE_SUFFIX_NGRAMS)
+
+	if len(indexes) != 2 {
+		t.Fatal("N-grams indexes MUST BE 2, found: ", indexes)
+	}
+}
+
+// ngramTestTransformer returns a HmacTransformer with the key,
+// the n-gram mode requires a keyed transformer
+func ngramTestTransformer(t *testing.T, key string) *HmacTransformer {
+	t.Helper()
+
+	transformer, err := NewHmacTransformer(NewStaticKeyProvider([]byte(key)), HMAC_ALGORITHM_SHA256)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	return transformer
+}
+
+// assertNgramSearch checks the SEARCH_TYPE_CONTAINS search
+// returns the expected source reference IDs, in any order
+func assertNgramSearch(t *testing.T, name string, store StoreInterface, needle string, expected []string) {
+	t.Helper()
+
+	refIDs, err := store.Search(needle, SEARCH_TYPE_CONTAINS)
+
+	if err != nil {
+		t.Fatal(name, "unexpected error:", err)
+	}
+
+	slices.Sort(refIDs)
+
+	if !slices.Equal(refIDs, expected) {
+		t.Fatal(name, "Search for '"+needle+"' MUST return [", strings.Join(expected, ", "), "], found: ", refIDs)
+	}
+}
+
+// assertNgramRowCount checks the number of rows of the n-grams table
+func assertNgramRowCount(t *testing.T, store *storeImplementation, expected int64) {
+	t.Helper()
+
+	var count int64
+
+	if err := store.db.QueryRow("SELECT COUNT(*) FROM " + store.ngramsTableName()).Scan(&count); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if count != expected {
+		t.Fatal("N-gram rows MUST BE ", expected, ", found: ", count)
+	}
+}
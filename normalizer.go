@@ -0,0 +1,81 @@
+package blindindexstore
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizerInterface normalizes a plaintext value before it is transformed.
+// The normalizers of the store are applied in order, identically to the
+// indexed values and to the searched needles, so that e.g. "John@Example.com "
+// finds "john@example.com".
+type NormalizerInterface interface {
+	Normalize(string) string
+}
+
+// TrimNormalizer removes the leading and trailing white space
+type TrimNormalizer struct{}
+
+var _ NormalizerInterface = new(TrimNormalizer)
+
+func (n *TrimNormalizer) Normalize(v string) string {
+	return strings.TrimSpace(v)
+}
+
+// LowercaseNormalizer maps the value to lower case
+type LowercaseNormalizer struct{}
+
+var _ NormalizerInterface = new(LowercaseNormalizer)
+
+func (n *LowercaseNormalizer) Normalize(v string) string {
+	return strings.ToLower(v)
+}
+
+// NFKCNormalizer applies the Unicode NFKC normalization, so that e.g.
+// the full-width "ＡＢＣ" and the ligature "ﬁ" match "ABC" and "fi"
+type NFKCNormalizer struct{}
+
+var _ NormalizerInterface = new(NFKCNormalizer)
+
+func (n *NFKCNormalizer) Normalize(v string) string {
+	return norm.NFKC.String(v)
+}
+
+// WhitespaceCollapseNormalizer replaces each run of white space with a single space
+type WhitespaceCollapseNormalizer struct{}
+
+var _ NormalizerInterface = new(WhitespaceCollapseNormalizer)
+
+func (n *WhitespaceCollapseNormalizer) Normalize(v string) string {
+	return strings.Join(strings.Fields(v), " ")
+}
+
+// DiacriticsRemovalNormalizer removes the diacritics, so that e.g. "José" matches "Jose"
+type DiacriticsRemovalNormalizer struct{}
+
+var _ NormalizerInterface = new(DiacriticsRemovalNormalizer)
+
+func (n *DiacriticsRemovalNormalizer) Normalize(v string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	result, _, err := transform.String(t, v)
+
+	if err != nil {
+		return v
+	}
+
+	return result
+}
+
+// normalize applies the normalizers to the value, in order
+func normalize(normalizers []NormalizerInterface, v string) string {
+	for _, normalizer := range normalizers {
+		v = normalizer.Normalize(v)
+	}
+
+	return v
+}
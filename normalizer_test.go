@@ -0,0 +1,111 @@
+package blindindexstore
+
+import (
+	"testing"
+)
+
+func Test_Normalizers(t *testing.T) {
+	tests := []struct {
+		normalizer NormalizerInterface
+		value      string
+		expected   string
+	}{
+		{&TrimNormalizer{}, "  john@example.com \t\n", "john@example.com"},
+		{&LowercaseNormalizer{}, "John@Example.COM", "john@example.com"},
+		{&NFKCNormalizer{}, "ＪＯＨＮ ﬁle", "JOHN file"},
+		{&WhitespaceCollapseNormalizer{}, " John \t  Ronald\n Doe ", "John Ronald Doe"},
+		{&DiacriticsRemovalNormalizer{}, "José Müller Ångström", "Jose Muller Angstrom"},
+	}
+
+	for _, test := range tests {
+		normalized := test.normalizer.Normalize(test.value)
+
+		if normalized != test.expected {
+			t.Fatalf("%T: normalized value MUST BE '%s', found: '%s'", test.normalizer, test.expected, normalized)
+		}
+	}
+}
+
+func Test_Store_Normalizers(t *testing.T) {
+	normalizers := []NormalizerInterface{
+		&NFKCNormalizer{},
+		&TrimNormalizer{},
+		&WhitespaceCollapseNormalizer{},
+		&LowercaseNormalizer{},
+		&DiacriticsRemovalNormalizer{},
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 initDB(":memory:"),
+		TableName:          "test_blindindex_normalizers",
+		AutomigrateEnabled: true,
+		Transformer:        &Sha256Transformer{},
+		Normalizers:        normalizers,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	memoryStore, err := NewMemoryStore(NewMemoryStoreOptions{
+		Transformer: &Sha256Transformer{},
+		Normalizers: normalizers,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	for name, store := range map[string]StoreInterface{"sql": store, "memory": memoryStore} {
+		value := NewSearchValue().
+			SetSourceReferenceID("RefId01").
+			SetSearchValue("josé@example.com")
+
+		if err := store.SearchValueCreate(value); err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		if value.SearchValue() != sha256Transform("jose@example.com") {
+			t.Fatal(name, "Search value MUST be the hash of the normalized value, found: ", value.SearchValue())
+		}
+
+		for _, needle := range []string{"José@Example.com ", " JOSE@EXAMPLE.COM", "ｊｏｓé@example.com"} {
+			refIDs, err := store.Search(needle, SEARCH_TYPE_EQUALS)
+
+			if err != nil {
+				t.Fatal(name, "unexpected error:", err)
+			}
+
+			if len(refIDs) != 1 || refIDs[0] != "RefId01" {
+				t.Fatal(name, "Search for '"+needle+"' MUST return [RefId01], found: ", refIDs)
+			}
+		}
+
+		value.SetSearchValue(" Jane@Example.com")
+
+		if err := store.SearchValueUpdate(value); err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		count, err := store.SearchValueCount(SearchValueQueryOptions{
+			SearchValueIn: []string{"JANE@example.com"},
+		})
+
+		if err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		if count != 1 {
+			t.Fatal(name, "Count MUST BE 1 after the update, found: ", count)
+		}
+	}
+
+	_, err = NewMemoryStore(NewMemoryStoreOptions{
+		Transformer: &Sha256Transformer{},
+		Normalizers: []NormalizerInterface{nil},
+	})
+
+	if err == nil {
+		t.Fatal("error MUST NOT be nil for a nil normalizer")
+	}
+}
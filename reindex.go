@@ -0,0 +1,146 @@
+package blindindexstore
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/gouniverse/base/database"
+)
+
+// ReindexSourceFunc streams the plaintext values to index, by calling
+// yield for each of them. It must stop when yield returns false.
+type ReindexSourceFunc func(yield func(sourceReferenceID, plaintext string) bool) error
+
+// ReindexOptions define the options for Reindex
+type ReindexOptions struct {
+	// BatchSize is the number of rows inserted per transaction,
+	// defaults to the batch size of the store
+	BatchSize int
+
+	// ShadowTable builds the index in a fresh <table>_next table,
+	// which replaces the live table only once complete (see RebuildTable).
+	// Writes to the live table during the reindex are lost at the swap
+	ShadowTable bool
+
+	// AllowEmpty allows an empty shadow table to replace a non-empty
+	// live table, ignored unless ShadowTable is used
+	AllowEmpty bool
+
+	// ClearExisting hard deletes the existing rows before indexing,
+	// ignored when ShadowTable is used
+	ClearExisting bool
+}
+
+// Reindex rebuilds the index from the plaintext values streamed by the
+// source, and returns the number of indexed rows
+func (store *storeImplementation) Reindex(ctx context.Context, source ReindexSourceFunc, opts ReindexOptions) (int64, error) {
+	if source == nil {
+		return 0, errors.New("blind index store: reindex source is required")
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize < 1 {
+		batchSize = store.batchSize
+	}
+
+	if opts.ShadowTable {
+		indexed := int64(0)
+
+		err := store.RebuildTableCtx(ctx, func(next StoreInterface) (int64, error) {
+			var err error
+			indexed, err = next.Reindex(ctx, source, ReindexOptions{BatchSize: batchSize})
+			return indexed, err
+		}, RebuildTableOptions{AllowEmpty: opts.AllowEmpty})
+
+		return indexed, err
+	}
+
+	if opts.ClearExisting {
+		if err := store.deleteAll(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	return reindexBatches(ctx, store, source, batchSize)
+}
+
+// reindexBatches streams the plaintext values of the source into the store,
+// in batches of batchSize rows, and returns the number of indexed rows
+func reindexBatches(ctx context.Context, store StoreInterface, source ReindexSourceFunc, batchSize int) (int64, error) {
+	indexed := int64(0)
+	batch := make([]*SearchValue, 0, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := store.SearchValueCreateManyCtx(ctx, batch); err != nil {
+			return err
+		}
+
+		indexed += int64(len(batch))
+		batch = make([]*SearchValue, 0, batchSize)
+
+		return nil
+	}
+
+	var errFlush error
+
+	errSource := source(func(sourceReferenceID, plaintext string) bool {
+		if err := ctx.Err(); err != nil {
+			errFlush = err
+			return false
+		}
+
+		batch = append(batch, NewSearchValue().
+			SetSourceReferenceID(sourceReferenceID).
+			SetSearchValue(plaintext))
+
+		if len(batch) < batchSize {
+			return true
+		}
+
+		errFlush = flush()
+
+		return errFlush == nil
+	})
+
+	if errFlush != nil {
+		return indexed, errFlush
+	}
+
+	if errSource != nil {
+		return indexed, errSource
+	}
+
+	if err := flush(); err != nil {
+		return indexed, err
+	}
+
+	return indexed, nil
+}
+
+// deleteAll hard deletes all the rows of the table
+func (store *storeImplementation) deleteAll(ctx context.Context) error {
+	sqlStr, _, errSql := store.queryBuilder().
+		Delete(store.tableName).
+		ToSQL()
+
+	if errSql != nil {
+		return queryBuildError(errSql)
+	}
+
+	if store.debugEnabled {
+		log.Println(sqlStr)
+	}
+
+	return store.inNgramsTransaction(ctx, func(txStore *storeImplementation) error {
+		if _, err := database.Execute(txStore.toQueryableContext(ctx), sqlStr); err != nil {
+			return err
+		}
+
+		return txStore.ngramsDelete(ctx)
+	})
+}
@@ -0,0 +1,131 @@
+package blindindexstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func Test_Store_Reindex(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_reindex",
+		AutomigrateEnabled: true,
+		Transformer:        &Sha256Transformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.SearchValueCreate(NewSearchValue().
+		SetSourceReferenceID("RefIdStale").
+		SetSearchValue("stale@test.com"))
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	plaintexts := [][2]string{
+		{"RefId01", "john@test.com"},
+		{"RefId02", "jane@test.com"},
+		{"RefId03", "jim@test.com"},
+		{"RefId04", "joe@test.com"},
+		{"RefId05", "jill@test.com"},
+	}
+
+	source := func(yield func(sourceReferenceID, plaintext string) bool) error {
+		for _, plaintext := range plaintexts {
+			if !yield(plaintext[0], plaintext[1]) {
+				return nil
+			}
+		}
+		return nil
+	}
+
+	indexed, err := store.Reindex(context.Background(), source, ReindexOptions{
+		BatchSize:   2,
+		ShadowTable: true,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if indexed != 5 {
+		t.Fatal("Indexed rows MUST be 5, found: ", indexed)
+	}
+
+	count, err := store.SearchValueCount(SearchValueQueryOptions{WithDeleted: true})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if count != 5 {
+		t.Fatal("Live table MUST contain the 5 reindexed rows, found: ", count)
+	}
+
+	refIDs, err := store.Search("jim@test.com", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefId03" {
+		t.Fatal("Search MUST return [RefId03], found: ", refIDs)
+	}
+
+	refIDs, err = store.Search("stale@test.com", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 0 {
+		t.Fatal("Stale rows MUST be gone, found: ", refIDs)
+	}
+
+	// In place, clearing the existing rows
+	indexed, err = store.Reindex(context.Background(), source, ReindexOptions{
+		ClearExisting: true,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	count, err = store.SearchValueCount(SearchValueQueryOptions{WithDeleted: true})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if indexed != 5 || count != 5 {
+		t.Fatal("Indexed and live rows MUST be 5, found: ", indexed, count)
+	}
+
+	// A failing source leaves the live table untouched
+	errSource := errors.New("source unavailable")
+
+	_, err = store.Reindex(context.Background(), func(yield func(sourceReferenceID, plaintext string) bool) error {
+		yield("RefId06", "new@test.com")
+		return errSource
+	}, ReindexOptions{ShadowTable: true})
+
+	if !errors.Is(err, errSource) {
+		t.Fatal("Reindex MUST return the source error, found: ", err)
+	}
+
+	count, err = store.SearchValueCount(SearchValueQueryOptions{WithDeleted: true})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if count != 5 {
+		t.Fatal("Live table MUST be untouched, found: ", count)
+	}
+}
@@ -20,6 +20,8 @@ func NewSearchValue() *SearchValue {
 		SetID(uid.HumanUid()).
 		SetSourceReferenceID("").
 		SetSearchValue("").
+		SetSearchValueHash("").
+		SetTransformerVersion("").
 		SetCreatedAt(carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)).
 		SetUpdatedAt(carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC)).
 		SetDeletedAt(sb.MAX_DATETIME)
@@ -93,6 +95,28 @@ func (d *SearchValue) SetSearchValue(value string) *SearchValue {
 	return d
 }
 
+// SearchValueHash returns the hash of the transformed search value,
+// set by the store and used for indexed equality searches
+func (d *SearchValue) SearchValueHash() string {
+	return d.Get(COLUMN_SEARCH_VALUE_HASH)
+}
+
+func (d *SearchValue) SetSearchValueHash(hash string) *SearchValue {
+	d.Set(COLUMN_SEARCH_VALUE_HASH, hash)
+	return d
+}
+
+// TransformerVersion returns the version of the transformer
+// which produced the search value, empty if not versioned
+func (d *SearchValue) TransformerVersion() string {
+	return d.Get(COLUMN_TRANSFORMER_VERSION)
+}
+
+func (d *SearchValue) SetTransformerVersion(version string) *SearchValue {
+	d.Set(COLUMN_TRANSFORMER_VERSION, version)
+	return d
+}
+
 func (d *SearchValue) UpdatedAt() string {
 	return d.Get(COLUMN_UPDATED_AT)
 }
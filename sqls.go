@@ -1,9 +1,27 @@
 package blindindexstore
 
-import "github.com/gouniverse/sb"
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
 
+	"github.com/gouniverse/base/database"
+	"github.com/gouniverse/sb"
+)
+
+// tableIndex defines an index of the store table
+type tableIndex struct {
+	name    string
+	table   string
+	unique  bool
+	columns []string
+}
+
+// sqlTableCreate returns the SQL creating the baseline table, the columns
+// added later are added by the migrations (see migrationSteps)
 func (store *storeImplementation) sqlTableCreate() string {
-	sql := sb.NewBuilder(sb.DatabaseDriverName(store.db)).
+	sql := store.sqlBuilder().
 		Table(store.tableName).
 		Column(sb.Column{
 			Name:       COLUMN_ID,
@@ -36,3 +54,248 @@ func (store *storeImplementation) sqlTableCreate() string {
 
 	return sql
 }
+
+// sqlColumnAdd returns the SQL adding a string column, which defaults
+// to an empty string for the existing rows
+func (store *storeImplementation) sqlColumnAdd(column sb.Column) (string, error) {
+	sql, err := store.sqlBuilder().
+		TableColumnAdd(store.tableName, column)
+
+	if err != nil {
+		return "", err
+	}
+
+	// the builder does not support default values
+	return strings.TrimSuffix(sql, ";") + " DEFAULT '';", nil
+}
+
+// sqlNgramsTableCreate returns the SQL creating the n-grams table,
+// holding a row per n-gram hash of each search value
+func (store *storeImplementation) sqlNgramsTableCreate() string {
+	sql := store.sqlBuilder().
+		Table(store.ngramsTableName()).
+		Column(sb.Column{
+			Name:       COLUMN_ID,
+			Type:       sb.COLUMN_TYPE_STRING,
+			Length:     40,
+			PrimaryKey: true,
+		}).
+		Column(sb.Column{
+			Name:   COLUMN_SEARCH_VALUE_ID,
+			Type:   sb.COLUMN_TYPE_STRING,
+			Length: 40,
+		}).
+		Column(sb.Column{
+			Name:   COLUMN_NGRAM_HASH,
+			Type:   sb.COLUMN_TYPE_STRING,
+			Length: 64,
+		}).
+		CreateIfNotExists()
+
+	return sql
+}
+
+// sqlMigrationsTableCreate returns the SQL creating the migrations bookkeeping table
+func (store *storeImplementation) sqlMigrationsTableCreate() string {
+	sql := store.sqlBuilder().
+		Table(store.migrationsTableName()).
+		Column(sb.Column{
+			Name:       COLUMN_ID,
+			Type:       sb.COLUMN_TYPE_STRING,
+			Length:     100,
+			PrimaryKey: true,
+		}).
+		Column(sb.Column{
+			Name: COLUMN_APPLIED_AT,
+			Type: sb.COLUMN_TYPE_DATETIME,
+		}).
+		CreateIfNotExists()
+
+	return sql
+}
+
+// tableIndexes returns the indexes of the store table. The search_value
+// column (LONGTEXT) cannot be indexed, the fixed-length search_value_hash
+// column is indexed instead.
+//
+// MySQL index names are scoped to the table, so they are kept the same
+// when tables are renamed. SQLite and Postgres index names are scoped to
+// the schema, so they are prefixed with the table name.
+func (store *storeImplementation) tableIndexes() []tableIndex {
+	prefix := store.tableName + "_"
+	if store.dbDriverName == sb.DIALECT_MYSQL {
+		prefix = ""
+	}
+
+	indexes := []tableIndex{
+		{name: prefix + "idx_search_value_hash", table: store.tableName, columns: []string{COLUMN_SEARCH_VALUE_HASH}},
+		{name: prefix + "idx_source_reference_id", table: store.tableName, columns: []string{COLUMN_SOURCE_REFERENCE_ID}},
+		{name: prefix + "idx_deleted_at", table: store.tableName, columns: []string{COLUMN_DELETED_AT}},
+	}
+
+	if store.uniqueSearchValues {
+		indexes = append(indexes, tableIndex{
+			name:    prefix + "uidx_source_reference_id_search_value_hash_deleted_at",
+			table:   store.tableName,
+			unique:  true,
+			columns: []string{COLUMN_SOURCE_REFERENCE_ID, COLUMN_SEARCH_VALUE_HASH, COLUMN_DELETED_AT},
+		})
+	}
+
+	return indexes
+}
+
+// ngramsTableIndexes returns the indexes of the n-grams table,
+// named like the indexes of the store table (see tableIndexes)
+func (store *storeImplementation) ngramsTableIndexes() []tableIndex {
+	prefix := store.ngramsTableName() + "_"
+	if store.dbDriverName == sb.DIALECT_MYSQL {
+		prefix = ""
+	}
+
+	return []tableIndex{
+		{name: prefix + "idx_ngram_hash", table: store.ngramsTableName(), columns: []string{COLUMN_NGRAM_HASH}},
+		{name: prefix + "idx_search_value_id", table: store.ngramsTableName(), columns: []string{COLUMN_SEARCH_VALUE_ID}},
+	}
+}
+
+// sqlIndexCreate returns the SQL creating the index. MySQL does not
+// support CREATE INDEX IF NOT EXISTS, see indexExists
+func (store *storeImplementation) sqlIndexCreate(index tableIndex) string {
+	sql := "CREATE "
+
+	if index.unique {
+		sql += "UNIQUE "
+	}
+
+	sql += "INDEX "
+
+	if store.dbDriverName != sb.DIALECT_MYSQL {
+		sql += "IF NOT EXISTS "
+	}
+
+	columns := make([]string, 0, len(index.columns))
+	for _, column := range index.columns {
+		columns = append(columns, store.quoteIdentifier(column))
+	}
+
+	sql += store.quoteIdentifier(index.name) + " ON " + store.quoteIdentifier(index.table) + " (" + strings.Join(columns, ", ") + ");"
+
+	return sql
+}
+
+// createIndexes creates the missing indexes of the store table
+func (store *storeImplementation) createIndexes(ctx context.Context) error {
+	return store.createTableIndexes(ctx, store.tableIndexes())
+}
+
+// createTableIndexes creates the missing indexes
+func (store *storeImplementation) createTableIndexes(ctx context.Context, indexes []tableIndex) error {
+	for _, index := range indexes {
+		if store.dbDriverName == sb.DIALECT_MYSQL {
+			exists, err := store.indexExists(ctx, index.table, index.name)
+
+			if err != nil {
+				return err
+			}
+
+			if exists {
+				continue
+			}
+		}
+
+		sqlStr := store.sqlIndexCreate(index)
+
+		if store.debugEnabled {
+			log.Println(sqlStr)
+		}
+
+		if _, err := database.Execute(store.toQueryableContext(ctx), sqlStr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexExists checks whether the index exists on the MySQL table
+func (store *storeImplementation) indexExists(ctx context.Context, tableName, indexName string) (bool, error) {
+	sqlStr := "SELECT COUNT(*) AS count FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?"
+
+	if store.debugEnabled {
+		log.Println(sqlStr)
+	}
+
+	rows, err := database.SelectToMapString(store.toQueryableContext(ctx), sqlStr, tableName, indexName)
+
+	if err != nil {
+		return false, err
+	}
+
+	return len(rows) > 0 && rows[0]["count"] != "0", nil
+}
+
+// columnExists checks whether the column exists on the store table
+func (store *storeImplementation) columnExists(ctx context.Context, columnName string) (bool, error) {
+	sqlStr := ""
+
+	switch store.dbDriverName {
+	case sb.DIALECT_SQLITE:
+		sqlStr = "SELECT COUNT(*) AS count FROM pragma_table_info(?) WHERE name = ?"
+	case sb.DIALECT_MYSQL:
+		sqlStr = "SELECT COUNT(*) AS count FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?"
+	case sb.DIALECT_POSTGRES:
+		sqlStr = "SELECT COUNT(*) AS count FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2"
+	default:
+		return false, errors.New("blind index store: column check is not supported for driver " + store.dbDriverName)
+	}
+
+	if store.debugEnabled {
+		log.Println(sqlStr)
+	}
+
+	rows, err := database.SelectToMapString(store.toQueryableContext(ctx), sqlStr, store.tableName, columnName)
+
+	if err != nil {
+		return false, err
+	}
+
+	return len(rows) > 0 && rows[0]["count"] != "0", nil
+}
+
+// tableExists checks whether the table exists
+func (store *storeImplementation) tableExists(ctx context.Context, tableName string) (bool, error) {
+	sqlStr := ""
+
+	switch store.dbDriverName {
+	case sb.DIALECT_SQLITE:
+		sqlStr = "SELECT COUNT(*) AS count FROM sqlite_master WHERE type = 'table' AND name = ?"
+	case sb.DIALECT_MYSQL:
+		sqlStr = "SELECT COUNT(*) AS count FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+	case sb.DIALECT_POSTGRES:
+		sqlStr = "SELECT COUNT(*) AS count FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1"
+	default:
+		return false, errors.New("blind index store: table check is not supported for driver " + store.dbDriverName)
+	}
+
+	if store.debugEnabled {
+		log.Println(sqlStr)
+	}
+
+	rows, err := database.SelectToMapString(store.toQueryableContext(ctx), sqlStr, tableName)
+
+	if err != nil {
+		return false, err
+	}
+
+	return len(rows) > 0 && rows[0]["count"] != "0", nil
+}
+
+// quoteIdentifier quotes a table, column or index name for the dialect
+func (store *storeImplementation) quoteIdentifier(name string) string {
+	if store.dbDriverName == sb.DIALECT_MYSQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,310 @@
+package blindindexstore
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strconv"
+
+	"github.com/gouniverse/base/database"
+	"github.com/gouniverse/sb"
+)
+
+// RebuildTableBuildFunc populates the next table,
+// and returns the number of rows it created
+type RebuildTableBuildFunc func(next StoreInterface) (rowCount int64, err error)
+
+// RebuildTableOptions define the options for RebuildTable
+type RebuildTableOptions struct {
+	// AllowEmpty allows an empty next table to replace a non-empty live table
+	AllowEmpty bool
+}
+
+// RebuildTable builds a complete new <table>_next table with the build
+// callback, verifies its row count, and swaps it with the live table.
+// The previous live table is kept as <table>_prev for RollbackSwap.
+// Writes to the live table during the build are not copied to the next
+// table, so they are lost at the swap: pause the writes, or replay them.
+func (store *storeImplementation) RebuildTable(build RebuildTableBuildFunc, opts RebuildTableOptions) error {
+	return store.RebuildTableCtx(context.Background(), build, opts)
+}
+
+// RebuildTableCtx builds a complete new <table>_next table with the build
+// callback, verifies its row count, and swaps it with the live table.
+// The previous live table is kept as <table>_prev for RollbackSwap.
+// Writes to the live table during the build are not copied to the next
+// table, so they are lost at the swap: pause the writes, or replay them.
+func (store *storeImplementation) RebuildTableCtx(ctx context.Context, build RebuildTableBuildFunc, opts RebuildTableOptions) error {
+	if build == nil {
+		return errors.New("blind index store: rebuild build func is required")
+	}
+
+	next := store.withTableName(store.tableName + TABLE_SUFFIX_NEXT)
+
+	if err := next.dropTable(ctx); err != nil {
+		return err
+	}
+
+	if err := next.createTable(ctx); err != nil {
+		return err
+	}
+
+	expectedCount, err := build(next)
+
+	if err != nil {
+		return err
+	}
+
+	if err := rebuildTableVerify(ctx, store, next, expectedCount, opts); err != nil {
+		return err
+	}
+
+	return store.SwapTablesCtx(ctx)
+}
+
+// rebuildTableVerify checks the next table has the expected row count,
+// and that it does not replace a non-empty live table with an empty one
+func rebuildTableVerify(ctx context.Context, live, next StoreInterface, expectedCount int64, opts RebuildTableOptions) error {
+	nextCount, err := next.SearchValueCountCtx(ctx, SearchValueQueryOptions{WithDeleted: true})
+
+	if err != nil {
+		return err
+	}
+
+	if nextCount != expectedCount {
+		return errors.New("blind index store: next table has " + strconv.FormatInt(nextCount, 10) +
+			" rows, expected " + strconv.FormatInt(expectedCount, 10))
+	}
+
+	if nextCount == 0 && !opts.AllowEmpty {
+		liveCount, err := live.SearchValueCountCtx(ctx, SearchValueQueryOptions{WithDeleted: true})
+
+		if err != nil {
+			return err
+		}
+
+		if liveCount > 0 {
+			return errors.New("blind index store: next table is empty, live table has " + strconv.FormatInt(liveCount, 10) + " rows")
+		}
+	}
+
+	return nil
+}
+
+// SwapTables makes <table>_next the live table,
+// and keeps the live table as <table>_prev
+func (store *storeImplementation) SwapTables() error {
+	return store.SwapTablesCtx(context.Background())
+}
+
+// SwapTablesCtx makes <table>_next the live table,
+// and keeps the live table as <table>_prev
+func (store *storeImplementation) SwapTablesCtx(ctx context.Context) error {
+	nextTableName := store.tableName + TABLE_SUFFIX_NEXT
+	prevTableName := store.tableName + TABLE_SUFFIX_PREV
+
+	return store.renameTables(ctx, prevTableName, [][2]string{
+		{store.tableName, prevTableName},
+		{nextTableName, store.tableName},
+	})
+}
+
+// RollbackSwap makes <table>_prev the live table again,
+// and keeps the live table as <table>_next
+func (store *storeImplementation) RollbackSwap() error {
+	return store.RollbackSwapCtx(context.Background())
+}
+
+// RollbackSwapCtx makes <table>_prev the live table again,
+// and keeps the live table as <table>_next
+func (store *storeImplementation) RollbackSwapCtx(ctx context.Context) error {
+	nextTableName := store.tableName + TABLE_SUFFIX_NEXT
+	prevTableName := store.tableName + TABLE_SUFFIX_PREV
+
+	return store.renameTables(ctx, nextTableName, [][2]string{
+		{store.tableName, nextTableName},
+		{prevTableName, store.tableName},
+	})
+}
+
+// renameTables drops the dropTableName table, if it exists, and applies
+// the renames (old name, new name) in order, with their n-grams tables
+// in the n-gram mode, atomically where the dialect allows it:
+//   - MySQL: a single RENAME TABLE statement (DDL is not transactional)
+//   - SQLite, Postgres: a transaction
+func (store *storeImplementation) renameTables(ctx context.Context, dropTableName string, renames [][2]string) error {
+	switch store.dbDriverName {
+	case sb.DIALECT_MYSQL:
+		return store.renameTablesMysql(ctx, dropTableName, renames)
+	case sb.DIALECT_SQLITE, sb.DIALECT_POSTGRES:
+		return store.inTransaction(ctx, func(txStore *storeImplementation) error {
+			if err := txStore.withTableName(dropTableName).dropTable(ctx); err != nil {
+				return err
+			}
+
+			for _, rename := range renames {
+				if err := txStore.renameTable(ctx, rename[0], rename[1]); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return errors.New("blind index store: swapping tables is not supported for driver " + store.dbDriverName)
+}
+
+// renameTablesMysql applies the renames in a single RENAME TABLE statement.
+// The dropTableName table is renamed out of the way in the same statement,
+// and only dropped once the renames succeeded, so a failed swap keeps it
+func (store *storeImplementation) renameTablesMysql(ctx context.Context, dropTableName string, renames [][2]string) error {
+	tableRenames := slices.Clone(renames)
+	if store.ngramSize > 0 {
+		for _, rename := range renames {
+			tableRenames = append(tableRenames, [2]string{rename[0] + TABLE_SUFFIX_NGRAMS, rename[1] + TABLE_SUFFIX_NGRAMS})
+		}
+	}
+
+	for _, rename := range tableRenames {
+		exists, err := store.tableExists(ctx, rename[0])
+
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return errors.New("blind index store: table " + rename[0] + " does not exist")
+		}
+	}
+
+	dropTable := store.withTableName(dropTableName + TABLE_SUFFIX_DROP)
+
+	// the leftover of a previous swap, which failed to drop it
+	if err := dropTable.dropTable(ctx); err != nil {
+		return err
+	}
+
+	dropRenames := [][2]string{}
+
+	for _, rename := range [][2]string{
+		{dropTableName, dropTable.tableName},
+		{dropTableName + TABLE_SUFFIX_NGRAMS, dropTable.ngramsTableName()},
+	} {
+		exists, err := store.tableExists(ctx, rename[0])
+
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			dropRenames = append(dropRenames, rename)
+		}
+	}
+
+	sqlStr := "RENAME TABLE "
+	for index, rename := range append(dropRenames, tableRenames...) {
+		if index > 0 {
+			sqlStr += ", "
+		}
+		sqlStr += store.quoteIdentifier(rename[0]) + " TO " + store.quoteIdentifier(rename[1])
+	}
+	sqlStr += ";"
+
+	if store.debugEnabled {
+		log.Println(sqlStr)
+	}
+
+	if _, err := database.Execute(store.toQueryableContext(ctx), sqlStr); err != nil {
+		return err
+	}
+
+	return dropTable.dropTable(ctx)
+}
+
+// renameTable renames the table, and its n-grams table in the n-gram mode
+func (store *storeImplementation) renameTable(ctx context.Context, oldTableName, newTableName string) error {
+	oldTable := store.withTableName(oldTableName)
+	newTable := store.withTableName(newTableName)
+
+	if err := store.renameTableWithIndexes(ctx, oldTableName, newTableName, oldTable.tableIndexes(), newTable.tableIndexes()); err != nil {
+		return err
+	}
+
+	if store.ngramSize < 1 {
+		return nil
+	}
+
+	return store.renameTableWithIndexes(ctx, oldTable.ngramsTableName(), newTable.ngramsTableName(), oldTable.ngramsTableIndexes(), newTable.ngramsTableIndexes())
+}
+
+// renameTableWithIndexes renames the table, and its indexes with it, as
+// SQLite and Postgres index names are scoped to the schema (see tableIndexes).
+// SQLite cannot rename indexes, they are dropped and created again.
+func (store *storeImplementation) renameTableWithIndexes(ctx context.Context, oldTableName, newTableName string, oldIndexes, newIndexes []tableIndex) error {
+	sqls := []string{}
+
+	if store.dbDriverName == sb.DIALECT_SQLITE {
+		for _, index := range oldIndexes {
+			sqls = append(sqls, "DROP INDEX IF EXISTS "+store.quoteIdentifier(index.name)+";")
+		}
+	}
+
+	sqlStr, err := store.sqlBuilder().
+		TableRename(oldTableName, newTableName)
+
+	if err != nil {
+		return err
+	}
+
+	sqls = append(sqls, sqlStr)
+
+	if store.dbDriverName == sb.DIALECT_POSTGRES {
+		for i, index := range oldIndexes {
+			sqls = append(sqls, "ALTER INDEX IF EXISTS "+store.quoteIdentifier(index.name)+" RENAME TO "+store.quoteIdentifier(newIndexes[i].name)+";")
+		}
+	}
+
+	for _, sqlStr := range sqls {
+		if store.debugEnabled {
+			log.Println(sqlStr)
+		}
+
+		if _, err := database.Execute(store.toQueryableContext(ctx), sqlStr); err != nil {
+			return err
+		}
+	}
+
+	if store.dbDriverName == sb.DIALECT_SQLITE {
+		return store.createTableIndexes(ctx, newIndexes)
+	}
+
+	return nil
+}
+
+// withTableName returns a copy of the store operating on another table
+func (store *storeImplementation) withTableName(tableName string) *storeImplementation {
+	tableStore := *store
+	tableStore.tableName = tableName
+	return &tableStore
+}
+
+// dropTable drops the table of the store, and its n-grams table, if they exist
+func (store *storeImplementation) dropTable(ctx context.Context) error {
+	for _, tableName := range []string{store.tableName, store.ngramsTableName()} {
+		sqlStr := store.sqlBuilder().
+			Table(tableName).
+			DropIfExists()
+
+		if store.debugEnabled {
+			log.Println(sqlStr)
+		}
+
+		if _, err := database.Execute(store.toQueryableContext(ctx), sqlStr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
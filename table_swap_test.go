@@ -0,0 +1,163 @@
+package blindindexstore
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func Test_Store_RebuildTable(t *testing.T) {
+	db := initDB(":memory:")
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_rebuild",
+		AutomigrateEnabled: true,
+		Transformer:        &NoChangeTransformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	err = store.SearchValueCreate(NewSearchValue().
+		SetSourceReferenceID("RefIdOld").
+		SetSearchValue("old@test.com"))
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	// Row count mismatch, the live table is kept
+	err = store.RebuildTable(func(next StoreInterface) (int64, error) {
+		return 2, next.SearchValueCreate(NewSearchValue().
+			SetSourceReferenceID("RefIdNew").
+			SetSearchValue("new@test.com"))
+	}, RebuildTableOptions{})
+
+	if err == nil {
+		t.Fatal("error MUST NOT be nil for row count mismatch")
+	}
+
+	// Empty next table, the live table is kept
+	err = store.RebuildTable(func(next StoreInterface) (int64, error) {
+		return 0, nil
+	}, RebuildTableOptions{})
+
+	if err == nil {
+		t.Fatal("error MUST NOT be nil for empty next table")
+	}
+
+	refIDs, err := store.Search("old@test.com", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 {
+		t.Fatal("Live table MUST be kept, found: ", refIDs)
+	}
+
+	err = store.RebuildTable(func(next StoreInterface) (int64, error) {
+		return 1, next.SearchValueCreate(NewSearchValue().
+			SetSourceReferenceID("RefIdNew").
+			SetSearchValue("new@test.com"))
+	}, RebuildTableOptions{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	refIDs, err = store.SearchAny([]string{"old@test.com", "new@test.com"}, SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefIdNew" {
+		t.Fatal("Search MUST return [RefIdNew] after the swap, found: ", refIDs)
+	}
+
+	err = store.RollbackSwap()
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	refIDs, err = store.SearchAny([]string{"old@test.com", "new@test.com"}, SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefIdOld" {
+		t.Fatal("Search MUST return [RefIdOld] after the rollback, found: ", refIDs)
+	}
+
+	// The rolled back table is kept as next, and can be swapped in again
+	err = store.SwapTables()
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	refIDs, err = store.Search("new@test.com", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefIdNew" {
+		t.Fatal("Search MUST return [RefIdNew] after swapping again, found: ", refIDs)
+	}
+
+	// A failed swap keeps the previous table, the only rollback copy
+	if err := store.SwapTables(); err == nil {
+		t.Fatal("error MUST NOT be nil when the next table does not exist")
+	}
+
+	exists, err := store.(*storeImplementation).tableExists(context.Background(), "test_blindindex_rebuild"+TABLE_SUFFIX_PREV)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if !exists {
+		t.Fatal("Previous table MUST be kept after a failed swap")
+	}
+}
+
+func Test_Store_RebuildTable_Indexes(t *testing.T) {
+	db := initDB(":memory:")
+
+	tableName := "test_blindindex_rebuild_indexes"
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          tableName,
+		AutomigrateEnabled: true,
+		Transformer:        &NoChangeTransformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		err = store.RebuildTable(func(next StoreInterface) (int64, error) {
+			return 0, nil
+		}, RebuildTableOptions{AllowEmpty: true})
+
+		if err != nil {
+			t.Fatal("unexpected error:", err)
+		}
+
+		for _, table := range []string{tableName, tableName + TABLE_SUFFIX_PREV} {
+			indexes := sqliteIndexes(t, db, table)
+
+			if len(indexes) != 3 || !strings.HasPrefix(indexes[0], table+"_idx_") {
+				t.Fatal("Indexes of "+table+" MUST follow the table name, found: ", indexes)
+			}
+		}
+	}
+}
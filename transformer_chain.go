@@ -0,0 +1,73 @@
+package blindindexstore
+
+import (
+	"errors"
+	"slices"
+)
+
+// TransformerFunc adapts an ordinary function to TransformerInterface
+type TransformerFunc func(v string) string
+
+var _ TransformerInterface = TransformerFunc(nil)
+
+func (f TransformerFunc) Transform(v string) string {
+	return f(v)
+}
+
+// ChainTransformer composes transformers, the output of each transformer
+// is the input of the next one, e.g. a tokenizer followed by a keyed hash
+type ChainTransformer struct {
+	transformers []TransformerInterface
+}
+
+var _ TransformerInterface = (*ChainTransformer)(nil)
+
+// NewChainTransformer creates a chain of the transformers, applied in order
+func NewChainTransformer(transformers ...TransformerInterface) (*ChainTransformer, error) {
+	if len(transformers) == 0 {
+		return nil, errors.New("blind index store: chain requires at least one transformer")
+	}
+
+	for _, transformer := range transformers {
+		if transformer == nil {
+			return nil, errors.New("blind index store: chain transformer is nil")
+		}
+
+		if _, isVersioned := transformer.(VersionedTransformerInterface); isVersioned {
+			return nil, errors.New("blind index store: versioned transformers cannot be chained, add a chain for each version instead")
+		}
+	}
+
+	return &ChainTransformer{transformers: slices.Clone(transformers)}, nil
+}
+
+// Transformers returns the transformers of the chain, in order
+func (t *ChainTransformer) Transformers() []TransformerInterface {
+	return slices.Clone(t.transformers)
+}
+
+func (t *ChainTransformer) Transform(v string) string {
+	for _, transformer := range t.transformers {
+		v = transformer.Transform(v)
+	}
+
+	return v
+}
+
+// Keyed declares the chain is keyed if any of its transformers is,
+// as the output of a keyed step cannot be computed without the key
+func (t *ChainTransformer) Keyed() bool {
+	for _, transformer := range t.transformers {
+		if isKeyedTransformer(transformer) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SupportedSearchTypes returns the search types supported by all the
+// transformers of the chain, e.g. a hash at any step allows exact matches only
+func (t *ChainTransformer) SupportedSearchTypes() []string {
+	return intersectSearchTypes(t.transformers)
+}
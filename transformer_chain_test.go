@@ -0,0 +1,98 @@
+package blindindexstore
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_ChainTransformer(t *testing.T) {
+	reverse := TransformerFunc(func(v string) string {
+		runes := []rune(v)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes)
+	})
+
+	chain, err := NewChainTransformer(TransformerFunc(strings.ToUpper), reverse, &Sha256Transformer{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	expected := sha256Transform("CBA")
+
+	if chain.Transform("abc") != expected {
+		t.Fatal("Transformed value MUST BE '"+expected+"', found: ", chain.Transform("abc"))
+	}
+
+	if len(chain.Transformers()) != 3 {
+		t.Fatal("Transformers MUST BE 3, found: ", len(chain.Transformers()))
+	}
+
+	// nested chains
+	nested, err := NewChainTransformer(chain, &NoChangeTransformer{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if nested.Transform("abc") != expected {
+		t.Fatal("Transformed value MUST BE '"+expected+"', found: ", nested.Transform("abc"))
+	}
+}
+
+func Test_ChainTransformer_Validation(t *testing.T) {
+	if _, err := NewChainTransformer(); err == nil {
+		t.Fatal("error MUST NOT be nil for an empty chain")
+	}
+
+	if _, err := NewChainTransformer(&Sha256Transformer{}, nil); err == nil {
+		t.Fatal("error MUST NOT be nil for a nil transformer")
+	}
+
+	if _, err := NewChainTransformer(NewVersionedTransformer()); err == nil {
+		t.Fatal("error MUST NOT be nil for a versioned transformer")
+	}
+}
+
+func Test_Store_ChainTransformer(t *testing.T) {
+	chain, err := NewChainTransformer(TransformerFunc(strings.ToLower), &Rot13Transformer{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 initDB(":memory:"),
+		TableName:          "test_blindindex_chain",
+		AutomigrateEnabled: true,
+		Transformer:        chain,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	value := NewSearchValue().
+		SetSourceReferenceID("RefId01").
+		SetSearchValue("John@Test.com")
+
+	if err := store.SearchValueCreate(value); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if value.SearchValue() != "wbua@grfg.pbz" {
+		t.Fatal("Search value MUST BE 'wbua@grfg.pbz', found: ", value.SearchValue())
+	}
+
+	refIDs, err := store.Search("JOHN", SEARCH_TYPE_STARTS_WITH)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefId01" {
+		t.Fatal("Search MUST return [RefId01], found: ", refIDs)
+	}
+}
@@ -0,0 +1,96 @@
+package blindindexstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"strconv"
+)
+
+const HMAC_ALGORITHM_SHA256 = "sha256"
+const HMAC_ALGORITHM_SHA512 = "sha512"
+
+// HMAC_MIN_KEY_LENGTH is the minimum accepted key length in bytes
+const HMAC_MIN_KEY_LENGTH = 16
+
+// HmacTransformer is a keyed transformer using HMAC-SHA256 or HMAC-SHA512.
+// Unlike the Sha256Transformer the resulting index cannot be reversed with
+// a dictionary attack without knowing the key.
+//
+// Being a hash, it only supports SEARCH_TYPE_EQUALS searches.
+type HmacTransformer struct {
+	key       []byte
+	algorithm string
+	hashFunc  func() hash.Hash
+}
+
+var _ TransformerInterface = (*HmacTransformer)(nil)
+
+// NewHmacTransformer creates a new HMAC transformer. The key is read once
+// from the key provider. If algorithm is empty HMAC_ALGORITHM_SHA256 is used.
+func NewHmacTransformer(keyProvider KeyProviderInterface, algorithm string) (*HmacTransformer, error) {
+	if keyProvider == nil {
+		return nil, errors.New("blind index store: key provider is required")
+	}
+
+	if algorithm == "" {
+		algorithm = HMAC_ALGORITHM_SHA256
+	}
+
+	var hashFunc func() hash.Hash
+
+	switch algorithm {
+	case HMAC_ALGORITHM_SHA256:
+		hashFunc = sha256.New
+	case HMAC_ALGORITHM_SHA512:
+		hashFunc = sha512.New
+	default:
+		return nil, errors.New("blind index store: unsupported hmac algorithm " + algorithm)
+	}
+
+	key, err := keyProvider.Key()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) < HMAC_MIN_KEY_LENGTH {
+		return nil, errors.New("blind index store: hmac key must be at least " + strconv.Itoa(HMAC_MIN_KEY_LENGTH) + " bytes")
+	}
+
+	return &HmacTransformer{
+		key:       key,
+		algorithm: algorithm,
+		hashFunc:  hashFunc,
+	}, nil
+}
+
+// Algorithm returns the HMAC algorithm in use
+func (t *HmacTransformer) Algorithm() string {
+	return t.algorithm
+}
+
+// Transform returns the hex encoded HMAC of the value
+func (t *HmacTransformer) Transform(v string) string {
+	mac := hmac.New(t.hashFunc, t.key)
+	mac.Write([]byte(v))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SupportedSearchTypes declares the HMACs only support exact matches
+func (t *HmacTransformer) SupportedSearchTypes() []string {
+	return []string{SEARCH_TYPE_EQUALS}
+}
+
+// Keyed declares the HMACs depend on the secret key
+func (t *HmacTransformer) Keyed() bool {
+	return true
+}
+
+// Equal compares two transformed values in constant time
+func (t *HmacTransformer) Equal(transformedA, transformedB string) bool {
+	return hmac.Equal([]byte(transformedA), []byte(transformedB))
+}
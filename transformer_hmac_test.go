@@ -0,0 +1,127 @@
+package blindindexstore
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// RFC 4231, test case 1
+var rfc4231Key = bytes.Repeat([]byte{0x0b}, 20)
+
+const rfc4231Data = "Hi There"
+
+func Test_HmacTransformer_Sha256_KnownVector(t *testing.T) {
+	transformer, err := NewHmacTransformer(NewStaticKeyProvider(rfc4231Key), HMAC_ALGORITHM_SHA256)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	expected := "b0344c61d8db38535ca8afceaf0bf12b881dc200c9833da726e9376c2e32cff7"
+
+	if transformer.Transform(rfc4231Data) != expected {
+		t.Fatal("Transformed value MUST BE '"+expected+"', found: ", transformer.Transform(rfc4231Data))
+	}
+}
+
+func Test_HmacTransformer_Sha512_KnownVector(t *testing.T) {
+	transformer, err := NewHmacTransformer(NewStaticKeyProvider(rfc4231Key), HMAC_ALGORITHM_SHA512)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	expected := "87aa7cdea5ef619d4ff0b4241a1d6cb02379f4e2ce4ec2787ad0b30545e17cde" +
+		"daa833b7d6b8a702038b274eaea3f4e4be9d914eeb61f1702e696c203a126854"
+
+	if transformer.Transform(rfc4231Data) != expected {
+		t.Fatal("Transformed value MUST BE '"+expected+"', found: ", transformer.Transform(rfc4231Data))
+	}
+}
+
+func Test_HmacTransformer_Validation(t *testing.T) {
+	_, err := NewHmacTransformer(nil, HMAC_ALGORITHM_SHA256)
+	if err == nil {
+		t.Fatal("error MUST NOT be nil for nil key provider")
+	}
+
+	_, err = NewHmacTransformer(NewStaticKeyProvider(rfc4231Key), "md5")
+	if err == nil {
+		t.Fatal("error MUST NOT be nil for unsupported algorithm")
+	}
+
+	_, err = NewHmacTransformer(NewStaticKeyProvider([]byte("short")), HMAC_ALGORITHM_SHA256)
+	if err == nil {
+		t.Fatal("error MUST NOT be nil for short key")
+	}
+
+	transformer, err := NewHmacTransformer(NewStaticKeyProvider(rfc4231Key), "")
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if transformer.Algorithm() != HMAC_ALGORITHM_SHA256 {
+		t.Fatal("Algorithm MUST default to sha256, found: ", transformer.Algorithm())
+	}
+
+	if !transformer.Equal(transformer.Transform("a"), transformer.Transform("a")) {
+		t.Fatal("Equal MUST be true for the same value")
+	}
+
+	if transformer.Equal(transformer.Transform("a"), transformer.Transform("b")) {
+		t.Fatal("Equal MUST be false for different values")
+	}
+}
+
+func Test_KeyProviders(t *testing.T) {
+	key := "0123456789abcdef0123456789abcdef"
+	expected := "d83537acef949b7fd891ec5b5a1c2651b6be051d652ce8d7e57f70a77230bcdc"
+
+	t.Setenv("BLINDINDEX_TEST_KEY", key)
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte(key+"\n"), 0600); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	providers := map[string]KeyProviderInterface{
+		"static": NewStaticKeyProvider([]byte(key)),
+		"env":    &EnvKeyProvider{Name: "BLINDINDEX_TEST_KEY"},
+		"file":   &FileKeyProvider{Path: keyFile},
+		"func": KeyProviderFunc(func() ([]byte, error) {
+			return []byte(key), nil
+		}),
+	}
+
+	for name, provider := range providers {
+		transformer, err := NewHmacTransformer(provider, HMAC_ALGORITHM_SHA256)
+
+		if err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		if transformer.Transform("user01@test.com") != expected {
+			t.Fatal(name, "Transformed value MUST BE '"+expected+"', found: ", transformer.Transform("user01@test.com"))
+		}
+	}
+
+	failing := map[string]KeyProviderInterface{
+		"static": NewStaticKeyProvider(nil),
+		"env":    &EnvKeyProvider{Name: "BLINDINDEX_TEST_KEY_NOT_SET"},
+		"file":   &FileKeyProvider{Path: filepath.Join(t.TempDir(), "missing")},
+		"func": KeyProviderFunc(func() ([]byte, error) {
+			return nil, errors.New("secrets manager unavailable")
+		}),
+	}
+
+	for name, provider := range failing {
+		_, err := NewHmacTransformer(provider, HMAC_ALGORITHM_SHA256)
+
+		if err == nil {
+			t.Fatal(name, "error MUST NOT be nil")
+		}
+	}
+}
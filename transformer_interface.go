@@ -39,6 +39,11 @@ func (t *Sha256Transformer) Transform(v string) string {
 	return sha256Transform(v)
 }
 
+// SupportedSearchTypes declares the hashes only support exact matches
+func (t *Sha256Transformer) SupportedSearchTypes() []string {
+	return []string{SEARCH_TYPE_EQUALS}
+}
+
 // Example custom transformer (do not use in production)
 type UniTransformer struct{}
 
@@ -0,0 +1,16 @@
+package blindindexstore
+
+// KeyedTransformerInterface is optionally implemented by transformers to
+// declare their output depends on a secret key, e.g. a HMAC. The n-gram
+// mode requires a keyed transformer: the n-grams are short, so hashed
+// without a key they are reversed by hashing every possible n-gram.
+type KeyedTransformerInterface interface {
+	Keyed() bool
+}
+
+// isKeyedTransformer checks the transformer declares it is keyed
+func isKeyedTransformer(transformer any) bool {
+	keyed, ok := transformer.(KeyedTransformerInterface)
+
+	return ok && keyed.Keyed()
+}
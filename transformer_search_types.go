@@ -0,0 +1,71 @@
+package blindindexstore
+
+import (
+	"slices"
+)
+
+// SupportedSearchTypesInterface is optionally implemented by transformers
+// to declare the search types their transformed values can answer,
+// e.g. a hash only supports SEARCH_TYPE_EQUALS. The transformers not
+// implementing it are assumed to support all the search types.
+type SupportedSearchTypesInterface interface {
+	SupportedSearchTypes() []string
+}
+
+// searchTypesAll lists all the search types, in order
+var searchTypesAll = []string{
+	SEARCH_TYPE_EQUALS,
+	SEARCH_TYPE_CONTAINS,
+	SEARCH_TYPE_STARTS_WITH,
+	SEARCH_TYPE_ENDS_WITH,
+}
+
+// supportedSearchTypes returns the search types declared by the transformer,
+// or all the search types if the transformer does not declare them
+func supportedSearchTypes(transformer any) []string {
+	declarer, ok := transformer.(SupportedSearchTypesInterface)
+
+	if !ok {
+		return slices.Clone(searchTypesAll)
+	}
+
+	return declarer.SupportedSearchTypes()
+}
+
+// intersectSearchTypes returns the search types supported by all the transformers
+func intersectSearchTypes(transformers []TransformerInterface) []string {
+	return slices.DeleteFunc(slices.Clone(searchTypesAll), func(searchType string) bool {
+		for _, transformer := range transformers {
+			if !slices.Contains(supportedSearchTypes(transformer), searchType) {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// validateTransformerSearchType checks the search type is a known one,
+// and is supported by each of the (possibly nil) transformers
+func validateTransformerSearchType(searchType string, transformers ...any) error {
+	if err := validateSearchType(searchType); err != nil {
+		return err
+	}
+
+	if searchType == "" {
+		searchType = SEARCH_TYPE_EQUALS
+	}
+
+	for _, transformer := range transformers {
+		supported := supportedSearchTypes(transformer)
+
+		if !slices.Contains(supported, searchType) {
+			return &UnsupportedSearchTypeError{
+				SearchType:           searchType,
+				SupportedSearchTypes: supported,
+			}
+		}
+	}
+
+	return nil
+}
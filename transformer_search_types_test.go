@@ -0,0 +1,147 @@
+package blindindexstore
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// equalsOnlyTransformerWithError is a hashing transformer with error,
+// declaring it only supports exact matches
+type equalsOnlyTransformerWithError struct{}
+
+func (t *equalsOnlyTransformerWithError) Transform(ctx context.Context, v string) (string, error) {
+	return sha256Transform(v), nil
+}
+
+func (t *equalsOnlyTransformerWithError) SupportedSearchTypes() []string {
+	return []string{SEARCH_TYPE_EQUALS}
+}
+
+func Test_SupportedSearchTypes(t *testing.T) {
+	chain, err := NewChainTransformer(TransformerFunc(strings.ToLower), &Rot13Transformer{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if !slices.Equal(chain.SupportedSearchTypes(), searchTypesAll) {
+		t.Fatal("Chain without hashes MUST support all search types, found: ", chain.SupportedSearchTypes())
+	}
+
+	hashed, err := NewChainTransformer(TransformerFunc(strings.ToLower), &Sha256Transformer{})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if !slices.Equal(hashed.SupportedSearchTypes(), []string{SEARCH_TYPE_EQUALS}) {
+		t.Fatal("Chain with a hash MUST support equals only, found: ", hashed.SupportedSearchTypes())
+	}
+
+	versioned := NewVersionedTransformer()
+
+	if err := versioned.AddVersion("", &NoChangeTransformer{}); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if !slices.Equal(versioned.SupportedSearchTypes(), searchTypesAll) {
+		t.Fatal("Versioned transformer MUST support all search types, found: ", versioned.SupportedSearchTypes())
+	}
+
+	if err := versioned.AddVersion("2026", &Sha256Transformer{}); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if !slices.Equal(versioned.SupportedSearchTypes(), []string{SEARCH_TYPE_EQUALS}) {
+		t.Fatal("Versioned transformer MUST support equals only, found: ", versioned.SupportedSearchTypes())
+	}
+}
+
+func Test_Store_UnsupportedSearchType(t *testing.T) {
+	sqlStore, err := NewStore(NewStoreOptions{
+		DB:                 initDB(":memory:"),
+		TableName:          "test_blindindex_unsupported_search_type",
+		AutomigrateEnabled: true,
+		Transformer:        &Sha256Transformer{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	memoryStore, err := NewMemoryStore(NewMemoryStoreOptions{
+		TransformerWithError: &equalsOnlyTransformerWithError{},
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	for name, store := range map[string]StoreInterface{"sql": sqlStore, "memory": memoryStore} {
+		value := NewSearchValue().
+			SetSourceReferenceID("RefId01").
+			SetSearchValue("john@test.com")
+
+		if err := store.SearchValueCreate(value); err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		for _, searchType := range []string{SEARCH_TYPE_CONTAINS, SEARCH_TYPE_STARTS_WITH, SEARCH_TYPE_ENDS_WITH} {
+			if _, err := store.Search("john", searchType); !errors.Is(err, ErrUnsupportedSearchType) {
+				t.Fatal(name, "error MUST BE ErrUnsupportedSearchType for "+searchType+", found: ", err)
+			}
+		}
+
+		if _, err := store.SearchAny([]string{"john"}, SEARCH_TYPE_CONTAINS); !errors.Is(err, ErrUnsupportedSearchType) {
+			t.Fatal(name, "error MUST BE ErrUnsupportedSearchType, found: ", err)
+		}
+
+		if _, err := store.SearchValueCount(SearchValueQueryOptions{
+			SearchValue: "john",
+			SearchType:  SEARCH_TYPE_STARTS_WITH,
+		}); !errors.Is(err, ErrUnsupportedSearchType) {
+			t.Fatal(name, "error MUST BE ErrUnsupportedSearchType, found: ", err)
+		}
+
+		// the search type only applies to a search value
+		for _, searchType := range []string{"", SEARCH_TYPE_CONTAINS} {
+			count, err := store.SearchValueCount(SearchValueQueryOptions{SearchType: searchType})
+
+			if err != nil {
+				t.Fatal(name, "unexpected error:", err)
+			}
+
+			if count != 1 {
+				t.Fatal(name, "Count MUST BE 1, found: ", count)
+			}
+
+			list, err := store.SearchValueList(SearchValueQueryOptions{SearchType: searchType})
+
+			if err != nil {
+				t.Fatal(name, "unexpected error:", err)
+			}
+
+			if len(list) != 1 {
+				t.Fatal(name, "List MUST return 1 entry, found: ", len(list))
+			}
+		}
+
+		// unknown search types keep their own error
+		if _, err := store.Search("john", "regex"); !errors.Is(err, ErrInvalidSearchType) {
+			t.Fatal(name, "error MUST BE ErrInvalidSearchType, found: ", err)
+		}
+
+		refIDs, err := store.Search("john@test.com", "")
+
+		if err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		if len(refIDs) != 1 || refIDs[0] != "RefId01" {
+			t.Fatal(name, "Search MUST return [RefId01], found: ", refIDs)
+		}
+	}
+}
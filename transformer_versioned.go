@@ -0,0 +1,197 @@
+package blindindexstore
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// VersionedTransformerInterface is implemented by transformers holding
+// multiple versions (e.g. key generations) side by side. The store tags
+// every row with the version which produced it, searches across all
+// the versions, and can re-key rows to the current version.
+type VersionedTransformerInterface interface {
+	TransformerInterface
+
+	// CurrentVersion is the version used for new rows
+	CurrentVersion() string
+
+	// Versions returns all the active versions
+	Versions() []string
+
+	// TransformVersion transforms the value with the given version
+	TransformVersion(version string, v string) (string, error)
+}
+
+// VersionedTransformer is a registry of transformers by version,
+// e.g. the same HmacTransformer with the key of each year.
+//
+// The last added version becomes the current one. The empty version ""
+// may be registered for rows created before versioning was enabled.
+type VersionedTransformer struct {
+	mu           sync.RWMutex
+	transformers map[string]TransformerInterface
+	versions     []string
+	current      string
+}
+
+var _ VersionedTransformerInterface = (*VersionedTransformer)(nil)
+
+func NewVersionedTransformer() *VersionedTransformer {
+	return &VersionedTransformer{
+		transformers: map[string]TransformerInterface{},
+	}
+}
+
+// AddVersion registers the transformer for the version, and makes it current
+func (t *VersionedTransformer) AddVersion(version string, transformer TransformerInterface) error {
+	if transformer == nil {
+		return errors.New("blind index store: transformer is required")
+	}
+
+	if _, isVersioned := transformer.(VersionedTransformerInterface); isVersioned {
+		return errors.New("blind index store: versioned transformers cannot be nested")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.transformers[version]; exists {
+		return errors.New("blind index store: transformer version " + version + " already exists")
+	}
+
+	t.transformers[version] = transformer
+	t.versions = append(t.versions, version)
+	t.current = version
+
+	return nil
+}
+
+// RemoveVersion retires the version, the current version cannot be removed
+func (t *VersionedTransformer) RemoveVersion(version string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.transformers[version]; !exists {
+		return errors.New("blind index store: transformer version " + version + " does not exist")
+	}
+
+	if version == t.current {
+		return errors.New("blind index store: current transformer version cannot be removed")
+	}
+
+	delete(t.transformers, version)
+	t.versions = slices.DeleteFunc(t.versions, func(v string) bool {
+		return v == version
+	})
+
+	return nil
+}
+
+// SetCurrentVersion changes the version used for new rows
+func (t *VersionedTransformer) SetCurrentVersion(version string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.transformers[version]; !exists {
+		return errors.New("blind index store: transformer version " + version + " does not exist")
+	}
+
+	t.current = version
+
+	return nil
+}
+
+func (t *VersionedTransformer) CurrentVersion() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.current
+}
+
+func (t *VersionedTransformer) Versions() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return slices.Clone(t.versions)
+}
+
+// Transform transforms the value with the current version
+func (t *VersionedTransformer) Transform(v string) string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	transformer, exists := t.transformers[t.current]
+
+	if !exists {
+		panic("blind index store: versioned transformer has no versions")
+	}
+
+	return transformer.Transform(v)
+}
+
+// SupportedSearchTypes returns the search types supported by all
+// the versions, as the searches run across all of them
+func (t *VersionedTransformer) SupportedSearchTypes() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	transformers := make([]TransformerInterface, 0, len(t.versions))
+	for _, version := range t.versions {
+		transformers = append(transformers, t.transformers[version])
+	}
+
+	return intersectSearchTypes(transformers)
+}
+
+// Keyed declares the versioned transformer is keyed if all its versions are
+func (t *VersionedTransformer) Keyed() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, version := range t.versions {
+		if !isKeyedTransformer(t.transformers[version]) {
+			return false
+		}
+	}
+
+	return len(t.versions) > 0
+}
+
+// searchVersions returns the versions a search runs across. A search
+// without any version must fail, rather than match all the rows
+func searchVersions(versioned VersionedTransformerInterface) ([]string, error) {
+	versions := versioned.Versions()
+
+	if len(versions) < 1 {
+		return nil, fmt.Errorf("%w: the versioned transformer has no version", ErrTransform)
+	}
+
+	return versions, nil
+}
+
+// transformVersion transforms the value with the given version,
+// and wraps the error of the transformer like transformValue
+func transformVersion(versioned VersionedTransformerInterface, version string, v string) (string, error) {
+	transformed, err := versioned.TransformVersion(version, v)
+
+	if err != nil {
+		return "", transformError(err)
+	}
+
+	return transformed, nil
+}
+
+func (t *VersionedTransformer) TransformVersion(version string, v string) (string, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	transformer, exists := t.transformers[version]
+
+	if !exists {
+		return "", errors.New("blind index store: transformer version " + version + " does not exist")
+	}
+
+	return transformer.Transform(v), nil
+}
@@ -0,0 +1,183 @@
+package blindindexstore
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_VersionedTransformer(t *testing.T) {
+	transformer := NewVersionedTransformer()
+
+	if err := transformer.AddVersion("v1", &NoChangeTransformer{}); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if err := transformer.AddVersion("v2", &Rot13Transformer{}); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if transformer.CurrentVersion() != "v2" {
+		t.Fatal("Current version MUST be v2, found: ", transformer.CurrentVersion())
+	}
+
+	if transformer.Transform("abc") != "nop" {
+		t.Fatal("Transform MUST use the current version, found: ", transformer.Transform("abc"))
+	}
+
+	transformed, err := transformer.TransformVersion("v1", "abc")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if transformed != "abc" {
+		t.Fatal("TransformVersion MUST use the given version, found: ", transformed)
+	}
+
+	if err := transformer.AddVersion("v1", &NoChangeTransformer{}); err == nil {
+		t.Fatal("error MUST NOT be nil for duplicate version")
+	}
+
+	if err := transformer.RemoveVersion("v2"); err == nil {
+		t.Fatal("error MUST NOT be nil when removing the current version")
+	}
+
+	if err := transformer.SetCurrentVersion("v3"); err == nil {
+		t.Fatal("error MUST NOT be nil for unknown version")
+	}
+
+	if err := transformer.RemoveVersion("v1"); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(transformer.Versions()) != 1 || transformer.Versions()[0] != "v2" {
+		t.Fatal("Versions MUST be [v2], found: ", transformer.Versions())
+	}
+}
+
+func Test_Store_Rekey(t *testing.T) {
+	db := initDB(":memory:")
+
+	key2024, err := NewHmacTransformer(NewStaticKeyProvider([]byte("key-2024-0123456789abcdef")), HMAC_ALGORITHM_SHA256)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	key2025, err := NewHmacTransformer(NewStaticKeyProvider([]byte("key-2025-0123456789abcdef")), HMAC_ALGORITHM_SHA256)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	transformer := NewVersionedTransformer()
+
+	if err := transformer.AddVersion("2024", key2024); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	store, err := NewStore(NewStoreOptions{
+		DB:                 db,
+		TableName:          "test_blindindex_rekey",
+		AutomigrateEnabled: true,
+		Transformer:        transformer,
+		BatchSize:          1,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	plaintexts := map[string]string{
+		"RefId01": "john@test.com",
+		"RefId02": "jane@test.com",
+	}
+
+	err = store.SearchValueCreate(NewSearchValue().
+		SetSourceReferenceID("RefId01").
+		SetSearchValue(plaintexts["RefId01"]))
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	// Rotate the key, the old version is still searchable
+	if err := transformer.AddVersion("2025", key2025); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	value := NewSearchValue().
+		SetSourceReferenceID("RefId02").
+		SetSearchValue(plaintexts["RefId02"])
+
+	err = store.SearchValueCreate(value)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if value.TransformerVersion() != "2025" {
+		t.Fatal("Transformer version MUST be 2025, found: ", value.TransformerVersion())
+	}
+
+	refIDs, err := store.SearchAny([]string{"john@test.com", "jane@test.com"}, SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 2 {
+		t.Fatal("Search MUST find rows of both versions, found: ", refIDs)
+	}
+
+	rekeyed, err := store.Rekey(func(searchValue SearchValue) (string, error) {
+		return plaintexts[searchValue.SourceReferenceID()], nil
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if rekeyed != 1 {
+		t.Fatal("Rekeyed rows MUST be 1, found: ", rekeyed)
+	}
+
+	// Retire the old version, all rows are still searchable
+	if err := transformer.RemoveVersion("2024"); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	refIDs, err = store.Search("john@test.com", SEARCH_TYPE_EQUALS)
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if len(refIDs) != 1 || refIDs[0] != "RefId01" {
+		t.Fatal("Search MUST return [RefId01], found: ", refIDs)
+	}
+
+	valueFound, err := store.SearchValueFindBySourceReferenceID("RefId01")
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	if valueFound.TransformerVersion() != "2025" || valueFound.SearchValue() != key2025.Transform("john@test.com") {
+		t.Fatal("Rekeyed row MUST use version 2025, found: ", valueFound.TransformerVersion())
+	}
+
+	errSource := errors.New("source unavailable")
+
+	if err := transformer.AddVersion("2026", &Sha256Transformer{}); err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	_, err = store.Rekey(func(searchValue SearchValue) (string, error) {
+		return "", errSource
+	})
+
+	if !errors.Is(err, errSource) {
+		t.Fatal("Rekey MUST return the source error, found: ", err)
+	}
+}
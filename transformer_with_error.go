@@ -0,0 +1,38 @@
+package blindindexstore
+
+import (
+	"context"
+)
+
+// TransformerWithErrorInterface is implemented by transformers which can
+// fail, e.g. backed by a KMS or an HSM. Set it with the TransformerWithError
+// option, the store then propagates its errors (wrapped in ErrTransform)
+// from SearchValueCreate, SearchValueUpdate, Search, etc.
+type TransformerWithErrorInterface interface {
+	Transform(ctx context.Context, v string) (string, error)
+}
+
+// TransformerWithErrorFunc adapts an ordinary function to TransformerWithErrorInterface
+type TransformerWithErrorFunc func(ctx context.Context, v string) (string, error)
+
+var _ TransformerWithErrorInterface = TransformerWithErrorFunc(nil)
+
+func (f TransformerWithErrorFunc) Transform(ctx context.Context, v string) (string, error) {
+	return f(ctx, v)
+}
+
+// transformValue transforms the value with the transformer returning
+// errors if set, and with the transformer otherwise
+func transformValue(ctx context.Context, transformer TransformerInterface, transformerWithError TransformerWithErrorInterface, v string) (string, error) {
+	if transformerWithError == nil {
+		return transformer.Transform(v), nil
+	}
+
+	transformed, err := transformerWithError.Transform(ctx, v)
+
+	if err != nil {
+		return "", transformError(err)
+	}
+
+	return transformed, nil
+}
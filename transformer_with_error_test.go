@@ -0,0 +1,129 @@
+package blindindexstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var errKmsUnavailable = errors.New("kms unavailable")
+
+// failingTransformer hashes the values, and fails for the values containing "fail"
+var failingTransformer = TransformerWithErrorFunc(func(ctx context.Context, v string) (string, error) {
+	if strings.Contains(v, "fail") {
+		return "", errKmsUnavailable
+	}
+
+	return sha256Transform(v), nil
+})
+
+func Test_Store_TransformerWithError(t *testing.T) {
+	sqlStore, err := NewStore(NewStoreOptions{
+		DB:                   initDB(":memory:"),
+		TableName:            "test_blindindex_transformer_with_error",
+		AutomigrateEnabled:   true,
+		TransformerWithError: failingTransformer,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	memoryStore, err := NewMemoryStore(NewMemoryStoreOptions{
+		TransformerWithError: failingTransformer,
+	})
+
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+
+	for name, store := range map[string]StoreInterface{"sql": sqlStore, "memory": memoryStore} {
+		value := NewSearchValue().
+			SetSourceReferenceID("RefId01").
+			SetSearchValue("john@test.com")
+
+		if err := store.SearchValueCreate(value); err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		if value.SearchValue() != sha256Transform("john@test.com") {
+			t.Fatal(name, "Search value MUST be transformed, found: ", value.SearchValue())
+		}
+
+		refIDs, err := store.Search("john@test.com", SEARCH_TYPE_EQUALS)
+
+		if err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		if len(refIDs) != 1 || refIDs[0] != "RefId01" {
+			t.Fatal(name, "Search MUST return [RefId01], found: ", refIDs)
+		}
+
+		failing := NewSearchValue().
+			SetSourceReferenceID("RefId02").
+			SetSearchValue("fail@test.com")
+
+		err = store.SearchValueCreate(failing)
+
+		if !errors.Is(err, ErrTransform) || !errors.Is(err, errKmsUnavailable) {
+			t.Fatal(name, "error MUST wrap ErrTransform and the transformer error, found: ", err)
+		}
+
+		if failing.SearchValue() != "fail@test.com" {
+			t.Fatal(name, "Search value MUST NOT be changed on failure, found: ", failing.SearchValue())
+		}
+
+		err = store.SearchValueCreateMany([]*SearchValue{
+			NewSearchValue().SetSourceReferenceID("RefId03").SetSearchValue("jane@test.com"),
+			NewSearchValue().SetSourceReferenceID("RefId04").SetSearchValue("fail@test.com"),
+		})
+
+		var createManyError *CreateManyError
+		if !errors.As(err, &createManyError) || !errors.Is(createManyError.Failures[1], ErrTransform) {
+			t.Fatal(name, "error MUST BE a CreateManyError reporting row 1, found: ", err)
+		}
+
+		value.SetSearchValue("fail@test.com")
+
+		if err := store.SearchValueUpdate(value); !errors.Is(err, ErrTransform) {
+			t.Fatal(name, "error MUST BE ErrTransform, found: ", err)
+		}
+
+		if _, err := store.Search("fail@test.com", SEARCH_TYPE_EQUALS); !errors.Is(err, ErrTransform) {
+			t.Fatal(name, "error MUST BE ErrTransform, found: ", err)
+		}
+
+		if _, err := store.SearchAny([]string{"john@test.com", "fail@test.com"}, SEARCH_TYPE_EQUALS); !errors.Is(err, ErrTransform) {
+			t.Fatal(name, "error MUST BE ErrTransform, found: ", err)
+		}
+
+		count, err := store.SearchValueCount(SearchValueQueryOptions{})
+
+		if err != nil {
+			t.Fatal(name, "unexpected error:", err)
+		}
+
+		if count != 1 {
+			t.Fatal(name, "Count MUST BE 1, found: ", count)
+		}
+	}
+}
+
+func Test_Store_TransformerWithError_Validation(t *testing.T) {
+	_, err := NewMemoryStore(NewMemoryStoreOptions{
+		Transformer:          &Sha256Transformer{},
+		TransformerWithError: failingTransformer,
+	})
+
+	if err == nil {
+		t.Fatal("error MUST NOT be nil when both transformers are set")
+	}
+
+	_, err = NewMemoryStore(NewMemoryStoreOptions{})
+
+	if err == nil {
+		t.Fatal("error MUST NOT be nil when no transformer is set")
+	}
+}